@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/importer"
+)
+
+// importLogic implements the 'import' subcommand, which imports articles from NDJSON or JSON array files
+// (or stdin) into the database.
+//
+// Usage: api-server import [-batch-size N] [-report FILE] [FILE ...]
+func importLogic(args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	batchSize := flags.Int("batch-size", importer.DefaultBatchSize, "number of articles written to the database at once")
+	reportPath := flags.String("report", "-", "file to write the JSON report to ('-' for stdout)")
+	config := core.NewConfig()
+	config.RegisterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s import [flags] [FILE ...]\n\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "Imports articles from NDJSON or JSON array files. Reads stdin if no file (or '-') is given.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Logs go to stderr so that the report can be written to stdout
+	logger := core.NewAppLogger(os.Stderr, log.INFO)
+	defer logger.Sync()
+
+	if err := config.LoadConfig(); err != nil {
+		logConfigErrors(logger, err)
+		return 1
+	}
+	logger.SetLevel(config.Options.LogLevel)
+
+	db, err := repository.NewDatabaseService(config.Database.Host, config.Database.Port,
+		config.Database.Username, config.Database.Password, config.Database.DBName)
+	if err != nil {
+		logger.Error(fmt.Sprintf("database error: %s", err.Error()), log.Field("type", "setup"))
+		return 1
+	}
+	defer db.Close()
+
+	imp := importer.NewImporter(logger, db, *batchSize)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	retCode := 0
+	for _, file := range files {
+		if err := importFile(imp, file); err != nil {
+			logger.Error(err.Error(), log.Field("type", "import"))
+			retCode = 1
+			break
+		}
+	}
+
+	if err := writeReport(imp.Report(), *reportPath); err != nil {
+		logger.Error(fmt.Sprintf("error writing report: %s", err.Error()), log.Field("type", "import"))
+		return 1
+	}
+
+	return retCode
+}
+
+// importFile imports a single file, '-' meaning stdin.
+func importFile(imp *importer.Importer, file string) error {
+	if file == "-" {
+		return imp.Import("stdin", os.Stdin)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return imp.Import(file, f)
+}
+
+// writeReport writes the import report as JSON to path, '-' meaning stdout.
+func writeReport(report importer.Report, path string) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
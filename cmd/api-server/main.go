@@ -1,39 +1,112 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/lifecycle"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/events"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/health"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/ingestion"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/tracing"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/webhook"
+	"go.opentelemetry.io/otel"
 )
 
 func main() {
-	retCode := mainLogic()
+	var retCode int
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		retCode = importLogic(os.Args[2:])
+	} else if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		retCode = secretsLogic(os.Args[2:])
+	} else {
+		retCode = mainLogic(os.Args[1:])
+	}
 	os.Exit(retCode)
 }
 
-func mainLogic() int {
+func mainLogic(args []string) int {
+	config := core.NewConfig()
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	printConfig := flags.Bool("print-config", false, "print the effective configuration (secrets redacted) and exit")
+	config.RegisterFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags]\n       %s import [flags] [FILE ...]\n       %s secrets encrypt|decrypt [flags] [FILE]\n\n",
+			os.Args[0], os.Args[0], os.Args[0])
+		fmt.Fprintf(flags.Output(), "Settings are read from flags, then environment variables, then the configuration file.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
 	// Setup logger
 	logger := core.NewAppLogger(os.Stdout, log.INFO)
 	defer logger.Sync()
 
+	if *printConfig {
+		if err := config.LoadConfig(); err != nil {
+			logConfigErrors(logger, err)
+			return 1
+		}
+		if err := config.Redacted().Print(os.Stdout); err != nil {
+			logger.Error(err.Error(), log.Field("type", "config"))
+			return 1
+		}
+		return 0
+	}
+
 	logger.Info("APP starting")
 
 	// Read config
 	logger.Info("reading configuration", log.Field("type", "setup"))
-	config := core.NewConfig()
 	if err := config.LoadConfig(); err != nil {
-		logger.Error(err.Error(), log.Field("type", "config"))
+		logConfigErrors(logger, err)
 		return 1
 	}
 
-	// TODO: Set log level after reading config
-	// something like this:
-	// logger.SetLevel(config.Options.LogLevel)
+	logger.SetLevel(config.Options.LogLevel)
+
+	// Setup tracing
+	// Trace context is propagated even without an exporter, so that logs carry the trace IDs of clients
+	otel.SetTextMapPropagator(tracing.Propagator())
+	if config.Tracing.Exporter != tracing.ExporterNone {
+		exporter, err := tracing.NewExporter(context.Background(), config.Tracing.Exporter,
+			config.Tracing.OTLPEndpoint, config.Tracing.OTLPInsecure)
+		if err != nil {
+			logger.Error(fmt.Sprintf("tracing error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+
+		tracerProvider, err := tracing.NewTracerProvider(exporter, config.Tracing.SampleRatio)
+		if err != nil {
+			logger.Error(fmt.Sprintf("tracing error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			logger.Error(fmt.Sprintf("tracing error: %s", err.Error()), log.Field("type", "tracing"))
+		}))
+
+		// Flush the spans still buffered on the way out
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := tracerProvider.Shutdown(ctx); err != nil {
+				logger.Error(fmt.Sprintf("tracing error: %s", err.Error()), log.Field("type", "shutdown"))
+			}
+		}()
+	}
 
 	// Setup Database
 	db, err := repository.NewDatabaseService(config.Database.Host, config.Database.Port,
@@ -44,10 +117,152 @@ func mainLogic() int {
 	}
 	defer db.Close()
 
-	server := api.NewServer(config.Webserver.Host, config.Webserver.Port, config.Options.DevMode, logger, db)
+	// Setup metrics
+	var appMetrics *metrics.Metrics
+	var repo core.Repository = db
+	if config.Metrics.Enabled {
+		appMetrics = metrics.New()
+		appMetrics.MustRegister(metrics.NewDBStatsCollector(db.Stats))
+		if err := db.Database.ObserveStatements(appMetrics.ObserveStatement); err != nil {
+			logger.Error(fmt.Sprintf("metrics error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+	}
+
+	// Setup event publishing
+	var eventPublisher core.EventPublisher
+	if config.Events.Sink == "file" {
+		sink, err := events.NewFileSink(config.Events.FilePath)
+		if err != nil {
+			logger.Error(fmt.Sprintf("events error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+		defer sink.Close()
+		eventPublisher = sink
+	}
+
+	// Setup health checks
+	checker := health.NewChecker(health.Dependency{Name: "database", Check: repo.HealthCheck,
+		Details: health.DBStatsDetails(db.Stats)})
+
+	serverOptions := []api.Option{api.WithHealthChecker(checker),
+		api.WithLogLevelControl(logger, config.Options.LogLevelRevertAfter)}
+	if config.Auth.APIKeys {
+		serverOptions = append(serverOptions, api.WithAPIKeyAuth(config.Auth.AdminKey))
+	}
+
+	if config.Auth.JWT.Enabled() {
+		validator, err := newJWTValidator(config.Auth.JWT)
+		if err != nil {
+			logger.Error(fmt.Sprintf("jwt error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+		serverOptions = append(serverOptions, api.WithJWTAuth(validator))
+	}
+
+	if len(config.Webserver.TrustedProxies) > 0 {
+		proxies, err := middleware.ParseProxies(config.Webserver.TrustedProxies)
+		if err != nil {
+			logger.Error(fmt.Sprintf("proxies error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+		serverOptions = append(serverOptions, api.WithTrustedProxies(proxies))
+	}
+
+	var readLimiter, writeLimiter *middleware.RateLimiter
+	if config.RateLimit.Read.Rate > 0 {
+		readLimiter = middleware.NewRateLimiter(config.RateLimit.Read.Rate, config.RateLimit.Read.Burst)
+	}
+	if config.RateLimit.Write.Rate > 0 {
+		writeLimiter = middleware.NewRateLimiter(config.RateLimit.Write.Rate, config.RateLimit.Write.Burst)
+	}
+	serverOptions = append(serverOptions, api.WithRateLimits(readLimiter, writeLimiter))
+
+	if appMetrics != nil {
+		serverOptions = append(serverOptions, api.WithMetrics(appMetrics))
+	}
+
+	serverOptions = append(serverOptions, api.WithCacheControl(config.Webserver.CacheControl))
+
+	if config.Compression.Enabled {
+		serverOptions = append(serverOptions, api.WithCompression(config.Compression.MinSize))
+	}
+
+	if config.CORS.Enabled() {
+		serverOptions = append(serverOptions, api.WithCORS(middleware.CORSOptions{
+			AllowedOrigins: config.CORS.AllowedOrigins,
+			AllowedMethods: config.CORS.AllowedMethods,
+			AllowedHeaders: config.CORS.AllowedHeaders,
+			MaxAge:         config.CORS.MaxAge,
+		}))
+	}
+
+	if config.Webserver.ValidateRequests {
+		validator, err := middleware.NewRequestValidator(api.OpenAPISpec())
+		if err != nil {
+			logger.Error(fmt.Sprintf("openapi error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+		serverOptions = append(serverOptions, api.WithRequestValidation(validator))
+	}
+
+	server := api.NewServer(config.Webserver.Host, config.Webserver.Port, config.Options.DevMode, logger, repo,
+		serverOptions...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Rotated database credentials are picked up by reconnecting
+	if config.Secrets.RefreshInterval > 0 {
+		go refreshDatabaseCredentials(ctx, logger, &config, db)
+	}
+
+	// Setup webhook dispatcher
+	dispatcher := webhook.NewDispatcher(logger, repo, config.Webhooks.MaxAttempts)
+	go dispatcher.Run(ctx)
+
+	// Setup event relay
+	// Events from the outbox are published to the sink, if any, and turned into webhook deliveries, so that every
+	// article added (through the API, from feeds or imported) is delivered
+	var publishers []core.EventPublisher
+	if eventPublisher != nil {
+		publishers = append(publishers, eventPublisher)
+	}
+	relay := events.NewRelay(logger, repo, append(publishers, dispatcher)...)
+	relay.Retention = config.Events.Retention
+	go relay.Run(ctx)
+
+	// Every instance follows the outbox to stream new articles to its own server-sent events clients
+	tailer := events.NewTailer(logger, repo, server.Stream)
+	if err := tailer.Start(); err != nil {
+		logger.Error(fmt.Sprintf("database error: %s", err.Error()), log.Field("type", "setup"))
+		return 1
+	}
+	// Clients reconnecting are replayed the events up to where the tailer started from the repository
+	server.Stream.Seek(tailer.LastID())
+	go tailer.Run(ctx)
+
+	// Setup feed poller
+
+	// Feeds in the configuration are added to the feed registry, unless they're already there
+	for _, feed := range config.Ingestion.Feeds {
+		_, err := repo.AddFeed(entities.Feed{URL: feed.URL, Provider: feed.Provider, Category: feed.Category, Enabled: true})
+		if _, ok := err.(*repository.DBDUPError); ok {
+			continue
+		} else if err != nil {
+			logger.Error(fmt.Sprintf("database error: %s", err.Error()), log.Field("type", "setup"))
+			return 1
+		}
+	}
+
+	poller := ingestion.NewPoller(logger, repo, config.Ingestion.PollInterval, config.Ingestion.MaxBackoff,
+		config.Ingestion.MaxFailures)
+	poller.Metrics = appMetrics
+	go poller.Run(ctx)
 
 	// Spawn SIGINT/SIGTERM listener
-	go lifecycle.TerminateHandler(logger, server)
+	// Readiness fails from the signal on, while requests are served for the drain delay
+	go lifecycle.TerminateHandler(logger, server, checker, config.Webserver.DrainDelay)
 
 	logger.Info("listenning for incoming requests", log.Field("type", "runtime"))
 	err = server.ListenAndServe()
@@ -59,3 +274,69 @@ func mainLogic() int {
 	logger.Info("APP gracefully terminated")
 	return 0
 }
+
+// refreshDatabaseCredentials reads the database credentials again every refresh interval, and reconnects to the
+// database when they changed, until ctx is done.
+func refreshDatabaseCredentials(ctx context.Context, logger log.Logger, config *core.Configuration,
+	db *repository.DatabaseService) {
+	ticker := time.NewTicker(config.Secrets.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		username, password, err := config.DatabaseCredentials()
+		if err != nil {
+			logger.Error(err.Error(), log.Field("type", "secrets"))
+			continue
+		}
+
+		changed, err := db.SetCredentials(username, password)
+		if err != nil {
+			// The current credentials are kept, and the new ones tried again on the next refresh
+			logger.Error(fmt.Sprintf("database error: checking new credentials: %s", err.Error()),
+				log.Field("type", "secrets"))
+		} else if changed {
+			logger.Info("database credentials changed, reconnected", log.Field("type", "secrets"))
+		}
+	}
+}
+
+// logConfigErrors logs each of the problems found in the configuration.
+func logConfigErrors(logger log.Logger, err error) {
+	errs, ok := err.(core.ConfigErrors)
+	if !ok {
+		errs = core.ConfigErrors{err}
+	}
+
+	for _, err := range errs {
+		logger.Error(err.Error(), log.Field("type", "config"))
+	}
+}
+
+// newJWTValidator returns a JWT validator with the keys in the configuration.
+func newJWTValidator(config core.JWTConfiguration) (*middleware.JWTValidator, error) {
+	validator := middleware.NewJWTValidator(config.Issuer, config.Audience)
+
+	if config.HMACSecret != "" {
+		validator.AddHMACSecret("", []byte(config.HMACSecret))
+	}
+
+	if config.PublicKeyFile != "" {
+		if err := validator.AddRSAPublicKeyFile("", config.PublicKeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if config.JWKSFile != "" {
+		if err := validator.AddJWKSFile(config.JWKSFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return validator, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/secrets"
+)
+
+// secretsLogic implements the 'secrets' subcommand, which encrypts and decrypts the secrets files of the file
+// secret provider.
+//
+// Usage: api-server secrets encrypt|decrypt -key-file FILE [-o FILE] [FILE]
+func secretsLogic(args []string) int {
+	if len(args) == 0 || (args[0] != "encrypt" && args[0] != "decrypt") {
+		fmt.Fprintf(os.Stderr, "Usage: %s secrets encrypt|decrypt [flags] [FILE]\n", os.Args[0])
+		return 2
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet("secrets "+command, flag.ContinueOnError)
+	keyFile := flags.String("key-file", "", "file holding the base64 encoded key (e.g. generated with 'openssl rand -base64 32')")
+	outputPath := flags.String("o", "-", "file to write the output to ('-' for stdout)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s secrets %s [flags] [FILE]\n\n", os.Args[0], command)
+		fmt.Fprintf(flags.Output(), "Encrypts a JSON object of secrets by name, or decrypts it. Reads stdin if no file (or '-') is given.\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *keyFile == "" || flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	// Logs go to stderr so that the output can be written to stdout
+	logger := core.NewAppLogger(os.Stderr, log.INFO)
+	defer logger.Sync()
+
+	key, err := secrets.ReadKeyFile(*keyFile)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error reading key: %s", err.Error()), log.Field("type", "secrets"))
+		return 1
+	}
+
+	input := os.Stdin
+	if path := flags.Arg(0); path != "" && path != "-" {
+		input, err = os.Open(path)
+		if err != nil {
+			logger.Error(fmt.Sprintf("error opening input: %s", err.Error()), log.Field("type", "secrets"))
+			return 1
+		}
+		defer input.Close()
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		logger.Error(fmt.Sprintf("error reading input: %s", err.Error()), log.Field("type", "secrets"))
+		return 1
+	}
+
+	if command == "encrypt" {
+		// The file provider reads a JSON object of secrets by name
+		var values map[string]string
+		if err := json.Unmarshal(data, &values); err != nil {
+			logger.Error(fmt.Sprintf("invalid input, must be a JSON object of secrets by name: %s", err.Error()),
+				log.Field("type", "secrets"))
+			return 1
+		}
+		data, err = secrets.Encrypt(data, key)
+	} else {
+		data, err = secrets.Decrypt(data, key)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("%s error: %s", command, err.Error()), log.Field("type", "secrets"))
+		return 1
+	}
+
+	if *outputPath == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*outputPath, data, 0o600)
+	}
+	if err != nil {
+		logger.Error(fmt.Sprintf("error writing output: %s", err.Error()), log.Field("type", "secrets"))
+		return 1
+	}
+	return 0
+}
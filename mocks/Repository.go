@@ -14,6 +14,27 @@ type Repository struct {
 	mock.Mock
 }
 
+// AddAPIKey provides a mock function with given fields: key
+func (_m *Repository) AddAPIKey(key entities.APIKey) (entities.APIKey, error) {
+	ret := _m.Called(key)
+
+	var r0 entities.APIKey
+	if rf, ok := ret.Get(0).(func(entities.APIKey) entities.APIKey); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Get(0).(entities.APIKey)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entities.APIKey) error); ok {
+		r1 = rf(key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // AddArticle provides a mock function with given fields: article
 func (_m *Repository) AddArticle(article entities.Article) error {
 	ret := _m.Called(article)
@@ -28,6 +49,247 @@ func (_m *Repository) AddArticle(article entities.Article) error {
 	return r0
 }
 
+// AddArticles provides a mock function with given fields: articles
+func (_m *Repository) AddArticles(articles entities.Articles) ([]string, error) {
+	ret := _m.Called(articles)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(entities.Articles) []string); ok {
+		r0 = rf(articles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entities.Articles) error); ok {
+		r1 = rf(articles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddFeed provides a mock function with given fields: feed
+func (_m *Repository) AddFeed(feed entities.Feed) (entities.Feed, error) {
+	ret := _m.Called(feed)
+
+	var r0 entities.Feed
+	if rf, ok := ret.Get(0).(func(entities.Feed) entities.Feed); ok {
+		r0 = rf(feed)
+	} else {
+		r0 = ret.Get(0).(entities.Feed)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entities.Feed) error); ok {
+		r1 = rf(feed)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddWebhook provides a mock function with given fields: webhook
+func (_m *Repository) AddWebhook(webhook entities.Webhook) (entities.Webhook, error) {
+	ret := _m.Called(webhook)
+
+	var r0 entities.Webhook
+	if rf, ok := ret.Get(0).(func(entities.Webhook) entities.Webhook); ok {
+		r0 = rf(webhook)
+	} else {
+		r0 = ret.Get(0).(entities.Webhook)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(entities.Webhook) error); ok {
+		r1 = rf(webhook)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddWebhookDeliveries provides a mock function with given fields: deliveries
+func (_m *Repository) AddWebhookDeliveries(deliveries entities.WebhookDeliveries) error {
+	ret := _m.Called(deliveries)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entities.WebhookDeliveries) error); ok {
+		r0 = rf(deliveries)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClaimDueWebhookDeliveries provides a mock function with given fields: now, lease, limit
+func (_m *Repository) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) (entities.WebhookDeliveries, error) {
+	ret := _m.Called(now, lease, limit)
+
+	var r0 entities.WebhookDeliveries
+	if rf, ok := ret.Get(0).(func(time.Time, time.Duration, int) entities.WebhookDeliveries); ok {
+		r0 = rf(now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.WebhookDeliveries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time, time.Duration, int) error); ok {
+		r1 = rf(now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimPendingEvents provides a mock function with given fields: claimant, now, lease, limit
+func (_m *Repository) ClaimPendingEvents(claimant string, now time.Time, lease time.Duration, limit int) (entities.Events, error) {
+	ret := _m.Called(claimant, now, lease, limit)
+
+	var r0 entities.Events
+	if rf, ok := ret.Get(0).(func(string, time.Time, time.Duration, int) entities.Events); ok {
+		r0 = rf(claimant, now, lease, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.Events)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time, time.Duration, int) error); ok {
+		r1 = rf(claimant, now, lease, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteFeed provides a mock function with given fields: id
+func (_m *Repository) DeleteFeed(id uint64) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeletePublishedEvents provides a mock function with given fields: before
+func (_m *Repository) DeletePublishedEvents(before time.Time) (int64, error) {
+	ret := _m.Called(before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(time.Time) int64); ok {
+		r0 = rf(before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhook provides a mock function with given fields: id
+func (_m *Repository) DeleteWebhook(id uint64) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAPIKeyByHash provides a mock function with given fields: hash
+func (_m *Repository) GetAPIKeyByHash(hash string) (entities.APIKey, error) {
+	ret := _m.Called(hash)
+
+	var r0 entities.APIKey
+	if rf, ok := ret.Get(0).(func(string) entities.APIKey); ok {
+		r0 = rf(hash)
+	} else {
+		r0 = ret.Get(0).(entities.APIKey)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAPIKeys provides a mock function with given fields:
+func (_m *Repository) GetAPIKeys() (entities.APIKeys, error) {
+	ret := _m.Called()
+
+	var r0 entities.APIKeys
+	if rf, ok := ret.Get(0).(func() entities.APIKeys); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.APIKeys)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetArticleStats provides a mock function with given fields: interval, byProvider, byCategory, from, to
+func (_m *Repository) GetArticleStats(interval string, byProvider bool, byCategory bool, from time.Time, to time.Time) (entities.ArticleCounts, error) {
+	ret := _m.Called(interval, byProvider, byCategory, from, to)
+
+	var r0 entities.ArticleCounts
+	if rf, ok := ret.Get(0).(func(string, bool, bool, time.Time, time.Time) entities.ArticleCounts); ok {
+		r0 = rf(interval, byProvider, byCategory, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.ArticleCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, bool, bool, time.Time, time.Time) error); ok {
+		r1 = rf(interval, byProvider, byCategory, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetArticles provides a mock function with given fields: provider, category, sorting, limit, after
 func (_m *Repository) GetArticles(provider string, category string, sorting string, limit int, after *time.Time) (entities.Articles, error) {
 	ret := _m.Called(provider, category, sorting, limit, after)
@@ -51,6 +313,182 @@ func (_m *Repository) GetArticles(provider string, category string, sorting stri
 	return r0, r1
 }
 
+// GetArticlesVersion provides a mock function with given fields: provider, category, sorting, after
+func (_m *Repository) GetArticlesVersion(provider string, category string, sorting string, after *time.Time) (entities.ArticlesVersion, error) {
+	ret := _m.Called(provider, category, sorting, after)
+
+	var r0 entities.ArticlesVersion
+	if rf, ok := ret.Get(0).(func(string, string, string, *time.Time) entities.ArticlesVersion); ok {
+		r0 = rf(provider, category, sorting, after)
+	} else {
+		r0 = ret.Get(0).(entities.ArticlesVersion)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string, *time.Time) error); ok {
+		r1 = rf(provider, category, sorting, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetEventsAfter provides a mock function with given fields: afterID, limit
+func (_m *Repository) GetEventsAfter(afterID uint64, limit int) (entities.Events, error) {
+	ret := _m.Called(afterID, limit)
+
+	var r0 entities.Events
+	if rf, ok := ret.Get(0).(func(uint64, int) entities.Events); ok {
+		r0 = rf(afterID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.Events)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, int) error); ok {
+		r1 = rf(afterID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFeed provides a mock function with given fields: id
+func (_m *Repository) GetFeed(id uint64) (entities.Feed, error) {
+	ret := _m.Called(id)
+
+	var r0 entities.Feed
+	if rf, ok := ret.Get(0).(func(uint64) entities.Feed); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(entities.Feed)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFeeds provides a mock function with given fields:
+func (_m *Repository) GetFeeds() (entities.Feeds, error) {
+	ret := _m.Called()
+
+	var r0 entities.Feeds
+	if rf, ok := ret.Get(0).(func() entities.Feeds); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.Feeds)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetLastEventID provides a mock function with given fields:
+func (_m *Repository) GetLastEventID() (uint64, error) {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhook provides a mock function with given fields: id
+func (_m *Repository) GetWebhook(id uint64) (entities.Webhook, error) {
+	ret := _m.Called(id)
+
+	var r0 entities.Webhook
+	if rf, ok := ret.Get(0).(func(uint64) entities.Webhook); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Get(0).(entities.Webhook)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64) error); ok {
+		r1 = rf(id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDeliveries provides a mock function with given fields: webhookID, limit
+func (_m *Repository) GetWebhookDeliveries(webhookID uint64, limit int) (entities.WebhookDeliveries, error) {
+	ret := _m.Called(webhookID, limit)
+
+	var r0 entities.WebhookDeliveries
+	if rf, ok := ret.Get(0).(func(uint64, int) entities.WebhookDeliveries); ok {
+		r0 = rf(webhookID, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.WebhookDeliveries)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(uint64, int) error); ok {
+		r1 = rf(webhookID, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhooks provides a mock function with given fields:
+func (_m *Repository) GetWebhooks() (entities.Webhooks, error) {
+	ret := _m.Called()
+
+	var r0 entities.Webhooks
+	if rf, ok := ret.Get(0).(func() entities.Webhooks); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(entities.Webhooks)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // HealthCheck provides a mock function with given fields:
 func (_m *Repository) HealthCheck() error {
 	ret := _m.Called()
@@ -64,3 +502,87 @@ func (_m *Repository) HealthCheck() error {
 
 	return r0
 }
+
+// MarkEventsPublished provides a mock function with given fields: ids, publishedAt
+func (_m *Repository) MarkEventsPublished(ids []uint64, publishedAt time.Time) error {
+	ret := _m.Called(ids, publishedAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]uint64, time.Time) error); ok {
+		r0 = rf(ids, publishedAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetAPIKeyEnabled provides a mock function with given fields: id, enabled
+func (_m *Repository) SetAPIKeyEnabled(id uint64, enabled bool) error {
+	ret := _m.Called(id, enabled)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64, bool) error); ok {
+		r0 = rf(id, enabled)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// StreamArticles provides a mock function with given fields: provider, category, after, afterGUID, fn
+func (_m *Repository) StreamArticles(provider string, category string, after *time.Time, afterGUID string, fn func(entities.Article) error) error {
+	ret := _m.Called(provider, category, after, afterGUID, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, *time.Time, string, func(entities.Article) error) error); ok {
+		r0 = rf(provider, category, after, afterGUID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateFeed provides a mock function with given fields: feed
+func (_m *Repository) UpdateFeed(feed entities.Feed) error {
+	ret := _m.Called(feed)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entities.Feed) error); ok {
+		r0 = rf(feed)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateFeedStatus provides a mock function with given fields: id, fetch
+func (_m *Repository) UpdateFeedStatus(id uint64, fetch entities.FeedFetch) error {
+	ret := _m.Called(id, fetch)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(uint64, entities.FeedFetch) error); ok {
+		r0 = rf(id, fetch)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWebhookDelivery provides a mock function with given fields: delivery
+func (_m *Repository) UpdateWebhookDelivery(delivery entities.WebhookDelivery) error {
+	ret := _m.Called(delivery)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(entities.WebhookDelivery) error); ok {
+		r0 = rf(delivery)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -3,7 +3,11 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/pprof"
@@ -11,20 +15,159 @@ import (
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/health"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/stream"
 )
 
 // Server is the webserver environment, which holds all its dependencies.
 type Server struct {
 	Logger log.Logger
 	Repo   core.Repository
+	// Stream broadcasts the article events to server-sent events clients. It's meant to be fed the events of
+	// the outbox (see events.Tailer).
+	Stream *stream.Broker
+	// Health checks the dependencies of the service for the liveness and readiness probes.
+	Health *health.Checker
 
 	Router     *gin.Engine
 	HTTPServer http.Server
+
+	// apiKeyAuth requires API keys (or tokens) on write endpoints.
+	apiKeyAuth bool
+	// adminKey authenticates requests to the admin endpoints.
+	adminKey string
+	// jwt validates bearer tokens and requires them (or API keys) on every endpoint, if set.
+	jwt *middleware.JWTValidator
+	// trustedProxies are the proxies whose X-Forwarded-For header is trusted for the client's IP address.
+	trustedProxies []*net.IPNet
+	// readLimiter and writeLimiter limit the rate of requests to read and write endpoints, if set.
+	readLimiter  *middleware.RateLimiter
+	writeLimiter *middleware.RateLimiter
+	// metrics records requests and ingested articles, and is exposed at /metrics, if set.
+	metrics *metrics.Metrics
+	// validator rejects requests not matching the OpenAPI document, if set.
+	validator *middleware.RequestValidator
+	// compression compresses responses of at least compressionMinSize bytes and decompresses batch bodies.
+	compression        bool
+	compressionMinSize int
+	// cors lets browsers make cross-origin requests from the origins it allows, if set.
+	cors *middleware.CORSOptions
+	// cacheControl holds the Cache-Control header of the responses of cacheable routes, by route.
+	cacheControl map[string]string
+	// logLevels changes the log level through the admin API, if set, reverting it after logLevelRevertAfter
+	// unless requests say otherwise.
+	logLevels           log.LevelController
+	logLevelRevertAfter time.Duration
+
+	// suffixRoutes holds the handlers of the routes dispatched by suffix, by the method and path of their route.
+	suffixRoutes map[string]map[string]gin.HandlerFunc
+}
+
+// Option configures optional features of the server.
+type Option func(s *Server)
+
+// WithAPIKeyAuth requires an API key on write endpoints and enables the admin endpoints managing API keys,
+// authenticated with the admin key.
+func WithAPIKeyAuth(adminKey string) Option {
+	return func(s *Server) {
+		s.apiKeyAuth = true
+		s.adminKey = adminKey
+	}
+}
+
+// WithJWTAuth requires a JWT with the 'articles:read' scope on read endpoints, 'articles:write' on write
+// endpoints and 'admin' on the admin endpoints. API keys, if enabled, are still accepted.
+func WithJWTAuth(validator *middleware.JWTValidator) Option {
+	return func(s *Server) {
+		s.jwt = validator
+	}
+}
+
+// WithRateLimits limits the rate of requests of each client to read and write endpoints. Either limiter can be
+// nil to leave those endpoints unlimited.
+func WithRateLimits(readLimiter *middleware.RateLimiter, writeLimiter *middleware.RateLimiter) Option {
+	return func(s *Server) {
+		s.readLimiter = readLimiter
+		s.writeLimiter = writeLimiter
+	}
+}
+
+// WithTrustedProxies identifies clients (in logs, traces and rate limits) by the address the given proxies
+// forwarded their requests for, rather than by the address of the proxy. Without it, X-Forwarded-For is ignored.
+func WithTrustedProxies(proxies []*net.IPNet) Option {
+	return func(s *Server) {
+		s.trustedProxies = proxies
+	}
+}
+
+// WithMetrics records metrics of the requests and the articles added through the API, and exposes m (along
+// with anything else registered in it) at /metrics.
+func WithMetrics(m *metrics.Metrics) Option {
+	return func(s *Server) {
+		s.metrics = m
+	}
+}
+
+// WithRequestValidation rejects requests to the API that don't match its OpenAPI document (see OpenAPISpec)
+// with a 400, before they reach the handlers.
+func WithRequestValidation(validator *middleware.RequestValidator) Option {
+	return func(s *Server) {
+		s.validator = validator
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of the successful responses of cacheable routes (the article
+// listings), by route: e.g. '/api/v1/articles' or '/api/v1/articles.rss'.
+func WithCacheControl(cacheControl map[string]string) Option {
+	return func(s *Server) {
+		s.cacheControl = cacheControl
+	}
+}
+
+// WithCompression compresses responses of at least minSize bytes with brotli or gzip, for clients accepting it,
+// and accepts compressed bodies on the batch endpoint.
+func WithCompression(minSize int) Option {
+	return func(s *Server) {
+		s.compression = true
+		s.compressionMinSize = minSize
+	}
+}
+
+// WithCORS lets browsers make cross-origin requests to the API, as configured by options.
+func WithCORS(options middleware.CORSOptions) Option {
+	return func(s *Server) {
+		s.cors = &options
+	}
+}
+
+// WithHealthChecker checks the service's health with checker, instead of a checker of its repository.
+func WithHealthChecker(checker *health.Checker) Option {
+	return func(s *Server) {
+		s.Health = checker
+	}
+}
+
+// WithLogLevelControl lets admins change the level of logger at runtime. Changes revert after revertAfter
+// unless requests say otherwise, so that debug logging isn't left on by accident (0 keeps them for good).
+func WithLogLevelControl(logger log.LevelController, revertAfter time.Duration) Option {
+	return func(s *Server) {
+		s.logLevels = logger
+		s.logLevelRevertAfter = revertAfter
+	}
 }
 
 // NewServer creates a new server.
-func NewServer(addr string, port int, devMode bool, logger log.Logger, repo core.Repository) *Server {
-	s := &Server{Logger: logger, Repo: repo}
+func NewServer(addr string, port int, devMode bool, logger log.Logger, repo core.Repository, options ...Option) *Server {
+	s := &Server{Logger: logger, Repo: repo, Stream: stream.NewBroker()}
+
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.Health == nil {
+		s.Health = health.NewChecker(health.Dependency{Name: "database", Check: repo.HealthCheck})
+	}
 
 	if !devMode {
 		gin.SetMode(gin.ReleaseMode)
@@ -33,18 +176,34 @@ func NewServer(addr string, port int, devMode bool, logger log.Logger, repo core
 	s.Router = gin.New()
 
 	s.Router.Use(
+		middleware.TrustedProxies(s.trustedProxies),
+		middleware.RequestID(),
 		middleware.GinReqLogger(logger, time.RFC3339, "request served", "http-router-mux"),
+		middleware.Tracing(),
 	)
+	if s.metrics != nil {
+		s.Router.Use(middleware.Metrics(s.metrics))
+	}
+	// Panics are recovered ahead of the other middlewares, so that they're logged and recorded as 500s whichever
+	// middleware or handler they come from
 	if !devMode {
 		s.Router.Use(gin.Recovery())
 	}
+	if s.cors != nil {
+		s.Router.Use(middleware.CORS(*s.cors, RespondWithError))
+	}
+	if s.compression {
+		s.Router.Use(middleware.Compress(s.compressionMinSize))
+	}
 
 	// Create http.Server
+	// Streaming endpoints (export and server-sent events) lift the write timeout on their own responses.
 	s.HTTPServer = http.Server{
 		Addr:           fmt.Sprintf("%s:%d", addr, port),
-		Handler:        s.Router,
+		Handler:        withConnWriter(s.Router),
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
+		IdleTimeout:    60 * time.Second,
 		MaxHeaderBytes: 1 << 20,
 	}
 
@@ -56,12 +215,109 @@ func NewServer(addr string, port int, devMode bool, logger log.Logger, repo core
 // setupRoutes creates routes for all handlers.
 func (s *Server) setupRoutes(devMode bool) {
 	s.Router.NoRoute(NoRoute)
+	if s.metrics != nil {
+		s.Router.GET("/metrics", gin.WrapH(s.metrics))
+	}
 	v1 := s.Router.Group("/api/v1")
 	v1.GET("/healthcheck", s.Healthcheck)
+	v1.GET("/openapi.json", s.GetOpenAPISpec)
+
+	authenticator := &middleware.Authenticator{Logger: s.Logger, Respond: RespondWithError, AdminKey: s.adminKey,
+		JWT: s.jwt}
+	if s.apiKeyAuth {
+		authenticator.Repo = s.Repo
+	}
+
+	// The probes are public, but only admins get the details of the dependencies
+	s.Router.GET("/livez", authenticator.Authenticate(), s.Livez)
+	s.Router.GET("/readyz", authenticator.Authenticate(), s.Readyz)
+
+	// Reads are public unless JWT authentication is enabled, writes are public unless any authentication is
+	// enabled and the admin endpoints are never public
+	var read, write gin.HandlersChain
+	if s.jwt != nil {
+		read = append(read, authenticator.RequireScope(middleware.ScopeArticlesRead))
+	}
+	if s.jwt != nil || s.apiKeyAuth {
+		write = append(write, authenticator.RequireScope(middleware.ScopeArticlesWrite))
+	}
+
+	admin := gin.HandlersChain{authenticator.RequireScope(middleware.ScopeAdmin)}
+	if s.adminKey == "" && s.jwt == nil {
+		// Nobody can be granted the admin scope
+		admin[0] = func(c *gin.Context) {
+			RespondWithError(c, 403, CodeAdminAPIDisabled, "admin API disabled")
+			c.Abort()
+		}
+	}
+
+	// Rate limits apply once clients are authenticated, so that they're identified by key rather than IP
+	if s.readLimiter != nil {
+		read = append(read, middleware.RateLimit(s.readLimiter, RespondWithError))
+	}
+	if s.writeLimiter != nil {
+		write = append(write, middleware.RateLimit(s.writeLimiter, RespondWithError))
+	}
+
+	// Batch bodies can be compressed, and must be decompressed before being validated
+	batch := append(gin.HandlersChain{}, write...)
+	if s.compression {
+		batch = append(batch, middleware.Decompress(maxBatchSize, RespondWithError))
+	}
+
+	// Requests are validated once clients are known to be allowed to make them
+	if s.validator != nil {
+		read = append(read, s.validator.Validate(RespondWithError))
+		write = append(write, s.validator.Validate(RespondWithError))
+		batch = append(batch, s.validator.Validate(RespondWithError))
+		admin = append(admin, s.validator.Validate(RespondWithError))
+	}
+
+	// Routes registered from here on (all but the health checks and the OpenAPI document) authenticate clients
+	v1.Use(authenticator.Authenticate())
+	reads := v1.Group("", read...)
+	writes := v1.Group("", write...)
+	admins := v1.Group("/admin", admin...)
+
+	reads.GET("/articles", s.GetArticles)
+	writes.POST("/articles", s.AddArticle)
+	v1.POST("/articles:batch", append(batch, s.AddArticles)...)
+	// gin treats ':' as the start of a path parameter, so routes like '/articles:export' and '/articles.rss'
+	// can't coexist and must be dispatched by suffix.
+	articleRoutes := map[string]gin.HandlerFunc{
+		":export": s.ExportArticles,
+		":stream": s.StreamArticles,
+		".rss":    s.GetArticlesRSS,
+		".atom":   s.GetArticlesAtom,
+	}
+	reads.GET("/articles:suffix", suffixRouter("suffix", articleRoutes))
+	s.suffixRoutes = map[string]map[string]gin.HandlerFunc{"GET /api/v1/articles:suffix": articleRoutes}
+
+	reads.GET("/stats/articles", s.GetArticleStats)
+
+	reads.GET("/feeds", s.GetFeeds)
+	writes.POST("/feeds", s.AddFeed)
+	reads.GET("/feeds/:id", s.GetFeed)
+	writes.PUT("/feeds/:id", s.UpdateFeed)
+	writes.DELETE("/feeds/:id", s.DeleteFeed)
+	reads.GET("/feeds.opml", s.ExportFeedsOPML)
+	writes.POST("/feeds.opml", s.ImportFeedsOPML)
 
-	v1.GET("/articles", s.GetArticles)
-	v1.POST("/articles", s.AddArticle)
-	v1.POST("/articles:batch", s.AddArticles)
+	// Webhooks are only visible to writers: their URLs can carry credentials and deliveries reveal subscribers
+	writes.GET("/webhooks", s.GetWebhooks)
+	writes.POST("/webhooks", s.AddWebhook)
+	writes.GET("/webhooks/:id", s.GetWebhook)
+	writes.DELETE("/webhooks/:id", s.DeleteWebhook)
+	writes.GET("/webhooks/:id/deliveries", s.GetWebhookDeliveries)
+
+	admins.GET("/apikeys", s.GetAPIKeys)
+	admins.POST("/apikeys", s.AddAPIKey)
+	admins.PUT("/apikeys/:id", s.UpdateAPIKey)
+	admins.DELETE("/apikeys/:id", s.RevokeAPIKey)
+	if s.logLevels != nil {
+		admins.GET("/loglevel", s.GetLogLevel)
+		admins.PUT("/loglevel", s.SetLogLevel)
+	}
 
 	// Profiler
 	// URL: https://<IP>:<PORT>/debug/pprof/
@@ -71,6 +327,77 @@ func (s *Server) setupRoutes(devMode bool) {
 	}
 }
 
+// repo returns the repository to use while handling a request, whose SQL statements are traced as part of the
+// request.
+func (s *Server) repo(c *gin.Context) core.Repository {
+	return core.RepositoryWithContext(c.Request.Context(), s.Repo)
+}
+
+// logger returns the logger to use while handling a request, which adds the request ID to every message.
+func (s *Server) logger(c *gin.Context) log.Logger {
+	return middleware.RequestLogger(c, s.Logger)
+}
+
+// Routes returns the routes of the server, listing the routes dispatched by suffix as routes of their own.
+func (s *Server) Routes() gin.RoutesInfo {
+	var routes gin.RoutesInfo
+	for _, route := range s.Router.Routes() {
+		handlers, ok := s.suffixRoutes[route.Method+" "+route.Path]
+		if !ok {
+			routes = append(routes, route)
+			continue
+		}
+
+		prefix := route.Path[:strings.LastIndex(route.Path, ":")]
+		for suffix, handler := range handlers {
+			routes = append(routes, gin.RouteInfo{
+				Method:      route.Method,
+				Path:        prefix + suffix,
+				Handler:     runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(),
+				HandlerFunc: handler,
+			})
+		}
+	}
+
+	return routes
+}
+
+// suffixRouter returns a handler that dispatches requests to one of the routes according to the value of the
+// path parameter, falling back to NoRoute.
+func suffixRouter(param string, routes map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handler, ok := routes[c.Param(param)]; ok {
+			middleware.SetRoute(c, strings.TrimSuffix(c.FullPath(), ":"+param)+c.Param(param))
+			handler(c)
+			return
+		}
+
+		NoRoute(c)
+	}
+}
+
+// connWriterKey is the request context key of the response writer of the connection, see withConnWriter.
+type connWriterKey struct{}
+
+// withConnWriter keeps the response writer of the connection in the request context, so that handlers can
+// reach it through gin's (and the middleware's) wrappers, e.g. to clear the write deadline.
+func withConnWriter(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), connWriterKey{}, w)))
+	})
+}
+
+// clearWriteDeadline lifts the server's write timeout on the response to a request, for responses streamed for
+// as long as it takes, like exports and server-sent events.
+func clearWriteDeadline(c *gin.Context) error {
+	w, ok := c.Request.Context().Value(connWriterKey{}).(http.ResponseWriter)
+	if !ok {
+		// Not served by the HTTP server (e.g. in tests), so there's no deadline
+		return nil
+	}
+	return http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
 // ListenAndServe listens and serves incoming requests.
 func (s *Server) ListenAndServe() error {
 	if err := s.HTTPServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -81,5 +408,7 @@ func (s *Server) ListenAndServe() error {
 
 // ShutDown gracefully shuts down server.
 func (s *Server) ShutDown(ctx context.Context) error {
+	// End server-sent events streams, which would otherwise hold the shutdown
+	s.Stream.Close()
 	return s.HTTPServer.Shutdown(ctx)
 }
@@ -0,0 +1,298 @@
+package api_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/health"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRateLimits(t *testing.T) {
+	mockDB := setupMockDB()
+	mockDB.On("GetAPIKeyByHash", middleware.HashAPIKey("key")).
+		Return(entities.APIKey{ID: 1, Label: "crawler", Enabled: true}, nil)
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB,
+		api.WithAPIKeyAuth(""),
+		api.WithRateLimits(middleware.NewRateLimiter(0.001, 2), nil))
+	router := server.Router
+
+	get := func(rawURL string, remoteAddr string, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", rawURL, nil)
+		require.NoError(t, err)
+		req.RemoteAddr = remoteAddr
+		if key != "" {
+			req.Header.Set(middleware.APIKeyHeader, key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		w := get("/api/v1/articles", "10.0.0.1:1234", "")
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	w := get("/api/v1/articles", "10.0.0.1:1234", "")
+	assert.Equal(t, 429, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "rate limit exceeded")
+
+	// Other IP addresses and API keys (even from the same address) have their own limit
+	assert.Equal(t, 200, get("/api/v1/articles", "10.0.0.2:1234", "").Code)
+	w = get("/api/v1/articles", "10.0.0.1:1234", "key")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+
+	// The health check isn't limited
+	mockDB.On("HealthCheck").Return(nil)
+	assert.Equal(t, 200, get("/api/v1/healthcheck", "10.0.0.1:1234", "").Code)
+}
+
+func TestHealthProbes(t *testing.T) {
+	const adminKey = "admin-key-0123456789"
+
+	mockDB := setupMockDB()
+	mockDB.On("HealthCheck").Return(errors.New("connection refused")).Once()
+	mockDB.On("HealthCheck").Return(nil)
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithAPIKeyAuth(adminKey),
+		api.WithJWTAuth(middleware.NewJWTValidator("https://gateway.example", "articles-mgmt")))
+	router := server.Router
+
+	get := func(rawURL string, key string) (int, map[string]interface{}, health.Report) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", rawURL, nil)
+		require.NoError(t, err)
+		if key != "" {
+			req.Header.Set(middleware.APIKeyHeader, key)
+		}
+		router.ServeHTTP(w, req)
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, body, report
+	}
+
+	// The database being down makes the service not ready, but it's still live (and the probes are public, but
+	// only tell anonymous clients the status)
+	code, body, _ := get("/readyz", "")
+	assert.Equal(t, 503, code)
+	assert.Equal(t, map[string]interface{}{"status": health.StatusDown}, body)
+
+	code, body, report := get("/livez", "")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, map[string]interface{}{"status": health.StatusUp}, body)
+
+	// Admins get the details
+	code, _, report = get("/livez", adminKey)
+	assert.Equal(t, 200, code)
+	require.Len(t, report.Dependencies, 1)
+	assert.Equal(t, "database", report.Dependencies[0].Name)
+	assert.Equal(t, "connection refused", report.Dependencies[0].Error)
+
+	code, _, report = get("/readyz", adminKey)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Equal(t, "connection refused", report.Dependencies[0].LastError)
+
+	// Shutting down
+	server.Health.Drain()
+	code, body, _ = get("/readyz", "")
+	assert.Equal(t, 503, code)
+	assert.Equal(t, map[string]interface{}{"status": health.StatusDown}, body)
+	_, _, report = get("/readyz", adminKey)
+	assert.True(t, report.ShuttingDown)
+}
+
+func TestMetrics(t *testing.T) {
+	mockDB := setupMockDB()
+	mockDB.On("AddArticle", mock.Anything).Return(nil).Once()
+	mockDB.On("AddArticle", mock.Anything).Return(&repository.DBDUPError{})
+
+	m := metrics.New()
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithMetrics(m), api.WithCompression(1))
+	router := server.Router
+	router.GET("/panic", func(c *gin.Context) { panic("handler panic") })
+
+	serve := func(method string, rawURL string, body string) {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(method, rawURL, strings.NewReader(body))
+		require.NoError(t, err)
+		router.ServeHTTP(w, req)
+	}
+
+	serve("GET", "/api/v1/articles", "")
+	serve("GET", "/api/v1/articles.rss", "")
+	serve("GET", "/api/v1/unknown", "")
+	article := `{"guid": "guid 1", "title": "title", "description": "description", "link": "https://example.com/1",
+		"published_date": "2020-05-10T12:00:00Z", "provider": "provider", "category": "category"}`
+	serve("POST", "/api/v1/articles", article)
+	serve("POST", "/api/v1/articles", article)
+	serve("GET", "/panic", "")
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	require.NoError(t, err)
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/articles",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/api/v1/articles.rss",status="200"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="POST",route="/api/v1/articles",status="409"} 1`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/panic",status="500"} 1`)
+	assert.Contains(t, body,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/articles",status="200"} 1`)
+	assert.Contains(t, body, `articles_ingested_total{result="created",source="api"} 1`)
+	assert.Contains(t, body, `articles_ingested_total{result="duplicate",source="api"} 1`)
+}
+
+// captureLogger records the fields of the messages logged.
+type captureLogger struct {
+	fields []log.FieldsMap
+}
+
+func (l *captureLogger) log(fields []log.FieldFunc) {
+	fieldsMap := log.FieldsMap{}
+	for _, f := range fields {
+		f(fieldsMap)
+	}
+	l.fields = append(l.fields, fieldsMap)
+}
+
+func (l *captureLogger) Debug(msg string, fields ...log.FieldFunc) { l.log(fields) }
+func (l *captureLogger) Info(msg string, fields ...log.FieldFunc)  { l.log(fields) }
+func (l *captureLogger) Warn(msg string, fields ...log.FieldFunc)  { l.log(fields) }
+func (l *captureLogger) Error(msg string, fields ...log.FieldFunc) { l.log(fields) }
+
+// contextRepository records the context its calls are bound to.
+type contextRepository struct {
+	*mocks.Repository
+	ctx *context.Context
+}
+
+func (r contextRepository) WithContext(ctx context.Context) core.Repository {
+	*r.ctx = ctx
+	return r
+}
+
+func TestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(tracing.Propagator())
+
+	var boundCtx context.Context
+	logger := &captureLogger{}
+	server := api.NewServer("", 9999, false, logger, contextRepository{Repository: setupMockDB(), ctx: &boundCtx})
+	router := server.Router
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/v1/articles?provider=errorCond&category=errorCond", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(w, req)
+	require.Equal(t, 500, w.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	// The request span continues the trace of the client, and the repository is bound to it, for the SQL
+	// statements to be traced as its children
+	assert.Equal(t, "GET /api/v1/articles", spans[0].Name())
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	require.NotNil(t, boundCtx)
+	assert.Equal(t, spans[0].SpanContext().SpanID(), trace.SpanContextFromContext(boundCtx).SpanID())
+
+	// Both the handler's and the request log lines carry the trace ID
+	require.Len(t, logger.fields, 2)
+	assert.Equal(t, traceID, logger.fields[0]["traceid"])
+	assert.Equal(t, traceID, logger.fields[1]["traceid"])
+}
+
+func TestCompression(t *testing.T) {
+	mockDB := setupMockDB()
+	mockDB.On("AddArticle", mock.Anything).Return(nil)
+
+	validator, err := middleware.NewRequestValidator(api.OpenAPISpec())
+	require.NoError(t, err)
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB,
+		api.WithCompression(100), api.WithRequestValidation(validator))
+	router := server.Router
+
+	// Compressed batches are decompressed before being validated
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	_, err = gz.Write([]byte(`[{"guid": "guid 9", "title": "title 9", "description": "description 9",
+		"link": "https://link9.com", "published_date": "2020-06-01T10:00:00Z", "provider": "provider 1",
+		"category": "category 1"}]`))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/api/v1/articles:batch", &body)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 204, w.Code, w.Body.String())
+	mockDB.AssertNumberOfCalls(t, "AddArticle", 1)
+
+	// Other write endpoints don't accept compressed bodies
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("POST", "/api/v1/articles", strings.NewReader("compressed"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 400, w.Code)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/api/v1/articles", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, []string{"Accept", "Accept-Encoding"}, w.Header().Values("Vary"))
+
+	reader, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	articles, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Contains(t, string(articles), `"guid":"guid 1"`)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// respondCacheable sends a successful response to a GET request with a strong ETag, lastModified as
+// Last-Modified (unless zero) and the Cache-Control of the route, if configured.
+// Requests whose If-None-Match (or, without it, If-Modified-Since) condition matches get a 304 without body.
+func (s *Server) respondCacheable(c *gin.Context, contentType string, body []byte, etag string,
+	lastModified time.Time) {
+
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(c.Request, etag, lastModified) {
+		s.respondNotModified(c, etag)
+		return
+	}
+
+	s.setCacheHeaders(c, etag)
+	c.Data(200, contentType, body)
+}
+
+// respondNotModified responds to a GET request whose conditions match the current representation with a 304.
+func (s *Server) respondNotModified(c *gin.Context, etag string) {
+	s.setCacheHeaders(c, etag)
+	c.Status(304)
+}
+
+// setCacheHeaders sets the ETag and the Cache-Control of the route, if configured, of a response.
+func (s *Server) setCacheHeaders(c *gin.Context, etag string) {
+	c.Header("ETag", etag)
+	if cacheControl, ok := s.cacheControl[middleware.GetRoute(c)]; ok {
+		c.Header("Cache-Control", cacheControl)
+	}
+}
+
+// listingETag returns the strong ETag of an article listing, given the version of the articles it lists: a hash
+// of the version, the URL the listing was requested with (which feeds link to) and its content type.
+// Listings are rendered the same way from the same articles, so it can be checked before getting them.
+func listingETag(c *gin.Context, contentType string, version entities.ArticlesVersion) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%d\n%d", requestURL(c), contentType, version.Count,
+		version.LastPublished.UnixNano())))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports whether the conditions of a request match the current representation, so that the
+// client's copy can be used (RFC 7232). If-Modified-Since is ignored when If-None-Match is given.
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			// If-None-Match uses the weak comparison
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ifModifiedSince := req.Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		// Last-Modified is sent with a precision of a second
+		return err == nil && !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// lastModified returns the newest published date of articles, or the zero time if there are none.
+func lastModified(articles entities.Articles) time.Time {
+	var latest time.Time
+	for _, article := range articles {
+		if article.PublishedTime.After(latest) {
+			latest = article.PublishedTime
+		}
+	}
+	return latest
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+)
+
+// ProblemContentType is the content type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// Error codes of the responses sent by the handlers, on top of the ones sent by the middleware.
+const (
+	CodeRouteNotFound    = "route_not_found"
+	CodeInvalidQuery     = "invalid_query"
+	CodeInvalidBody      = middleware.CodeInvalidBody
+	CodeValidationFailed = middleware.CodeValidationFailed
+	CodeArticleExists    = "article_already_exists"
+	CodeFeedNotFound     = "feed_not_found"
+	CodeFeedExists       = "feed_already_exists"
+	CodeWebhookNotFound  = "webhook_not_found"
+	CodeAPIKeyNotFound   = "api_key_not_found"
+	CodeAdminAPIDisabled = "admin_api_disabled"
+	CodeInternalError    = middleware.CodeInternalError
+)
+
+// Problem is the body of error responses, an RFC 7807 problem details object.
+// Type is always 'about:blank' and Title the HTTP status text: the kind of error is told by Code.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Instance  string `json:"instance"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the invalid fields of validation failures.
+	Errors []middleware.FieldError `json:"errors,omitempty"`
+}
+
+func init() {
+	// Name fields in validation errors as clients know them, after their JSON property or query parameter
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+// RespondWithError is a helper function to return an error according to the API specification.
+func RespondWithError(c *gin.Context, httpCode int, code string, message string,
+	fieldErrors ...middleware.FieldError) {
+
+	// Set before rendering, so that it isn't replaced with application/json
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(httpCode, Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(httpCode),
+		Status:    httpCode,
+		Detail:    message,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: middleware.GetRequestID(c),
+		Errors:    fieldErrors,
+	})
+}
+
+// RespondWithInvalidField responds to a request with an invalid parameter or body property with a 400.
+func RespondWithInvalidField(c *gin.Context, field string, message string) {
+	RespondWithError(c, 400, CodeValidationFailed, message,
+		middleware.FieldError{Field: field, Code: "invalid", Message: message})
+}
+
+// RespondWithBindingError responds to a request whose query parameters or body couldn't be bound with a 400.
+// Values that fail validation or don't parse as the type of their field are reported as field errors, with the
+// 'validation_failed' code. Malformed JSON is reported with the given code and a 'syntax' field error naming no
+// field, other errors (like an empty body) with the given code only.
+func RespondWithBindingError(c *gin.Context, code string, err error) {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		message := fmt.Sprintf("malformed JSON at offset %d: %s", syntaxError.Offset, syntaxError.Error())
+		RespondWithError(c, 400, code, message,
+			middleware.FieldError{Field: "", Code: "syntax", Message: message})
+		return
+	}
+
+	fieldErrors := bindingFieldErrors(c, err)
+	if len(fieldErrors) == 0 {
+		RespondWithError(c, 400, code, err.Error())
+		return
+	}
+
+	respondWithFieldErrors(c, fieldErrors)
+}
+
+// respondWithFieldErrors responds to a request with invalid fields with a 400.
+func respondWithFieldErrors(c *gin.Context, fieldErrors []middleware.FieldError) {
+	messages := make([]string, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		messages = append(messages, fieldError.Message)
+	}
+
+	RespondWithError(c, 400, CodeValidationFailed, strings.Join(messages, "; "), fieldErrors...)
+}
+
+// bindingFieldErrors returns the field errors a binding error is made of, if it's about fields: values failing
+// validation rules (coded after the rule) or not parsing as the type of their field (coded 'type').
+func bindingFieldErrors(c *gin.Context, err error) []middleware.FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fieldErrors := make([]middleware.FieldError, 0, len(validationErrors))
+		for _, validationError := range validationErrors {
+			fieldErrors = append(fieldErrors, middleware.FieldError{Field: validationError.Field(),
+				Code: validationError.Tag(), Message: fieldErrorMessage(validationError)})
+		}
+		return fieldErrors
+	}
+
+	var field, expected string
+	var typeError *json.UnmarshalTypeError
+	var numError *strconv.NumError
+	var timeError *time.ParseError
+	switch {
+	case errors.As(err, &typeError) && typeError.Field != "":
+		field, expected = typeError.Field, jsonTypeName(typeError.Type)
+	case errors.As(err, &numError):
+		// Query parameters are parsed with strconv, whose errors only tell the value
+		field, expected = queryParameterWithValue(c, numError.Num), strconvTypeName(numError.Func)
+	case errors.As(err, &timeError):
+		field, expected = queryParameterWithValue(c, timeError.Value), "an RFC 3339 date-time"
+	}
+	if field == "" {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s must be %s", field, expected)
+	return []middleware.FieldError{{Field: field, Code: "type", Message: message}}
+}
+
+// queryParameterWithValue returns the name of the first query parameter (in alphabetical order) with a value,
+// or an empty string if none has it.
+func queryParameterWithValue(c *gin.Context, value string) string {
+	query := c.Request.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range query[name] {
+			if v == value {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// jsonTypeName describes the JSON values a Go type can be decoded from.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "an RFC 3339 date-time"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// strconvTypeName describes the values a strconv parsing function accepts.
+func strconvTypeName(function string) string {
+	switch function {
+	case "ParseBool":
+		return "a boolean"
+	case "ParseFloat":
+		return "a number"
+	default:
+		return "an integer"
+	}
+}
+
+// fieldName returns the name of a field in JSON bodies or query parameters.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name := strings.Split(field.Tag.Get(key), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldErrorMessage describes a validation error of a field.
+func fieldErrorMessage(err validator.FieldError) string {
+	unit := ""
+	if err.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch err.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", err.Field())
+	case "url":
+		return fmt.Sprintf("%s must be a valid URL", err.Field())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s%s", err.Field(), err.Param(), unit)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s%s", err.Field(), err.Param(), unit)
+	default:
+		return fmt.Sprintf("%s failed the '%s' validation", err.Field(), err.Tag())
+	}
+}
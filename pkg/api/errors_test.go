@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorResponses(t *testing.T) {
+	mockDB := setupMockDB()
+	mockDB.On("GetFeed", mock.Anything).Return(entities.Feed{}, &repository.DBNotFoundError{})
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+	router := server.Router
+
+	validArticle := `{"guid": "guid 1", "title": "title 1", "description": "description 1",
+		"link": "https://link1.com", "published_date": "2020-06-01T10:00:00Z", "provider": "provider 1",
+		"category": "category 1"}`
+
+	tests := map[string]struct {
+		method         string
+		rawURL         string
+		body           string
+		expectedStatus int
+		expectedCode   string
+		expectedErrors []middleware.FieldError
+	}{
+		"no route": {method: "GET", rawURL: "/api/v1/nothing", expectedStatus: 404,
+			expectedCode: api.CodeRouteNotFound},
+		"not found": {method: "GET", rawURL: "/api/v1/feeds/7", expectedStatus: 404,
+			expectedCode: api.CodeFeedNotFound},
+		"invalid path parameter": {method: "GET", rawURL: "/api/v1/feeds/abc", expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "id", Code: "invalid", Message: "feed id must be a positive integer"}}},
+		"invalid query parameter": {method: "GET", rawURL: "/api/v1/articles?limit=0", expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{{Field: "limit", Code: "invalid",
+				Message: "limit query parameter can be a minimum of 1 and a maximum of 200"}}},
+		"unparsable query parameter": {method: "GET", rawURL: "/api/v1/articles?limit=abc", expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "limit", Code: "type", Message: "limit must be an integer"}}},
+		"unparsable time query parameter": {method: "GET", rawURL: "/api/v1/articles?after=yesterday",
+			expectedStatus: 400, expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "after", Code: "type", Message: "after must be an RFC 3339 date-time"}}},
+		"malformed body": {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": }`, expectedStatus: 400,
+			expectedCode: api.CodeInvalidBody,
+			expectedErrors: []middleware.FieldError{{Field: "", Code: "syntax",
+				Message: "malformed JSON at offset 9: invalid character '}' looking for beginning of value"}}},
+		"truncated body": {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": `, expectedStatus: 400,
+			expectedCode: api.CodeInvalidBody},
+		"mistyped body property": {method: "POST", rawURL: "/api/v1/feeds",
+			body: `{"url": 7, "provider": "provider", "category": "category"}`, expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "url", Code: "type", Message: "url must be a string"}}},
+		"invalid batch item": {method: "POST", rawURL: "/api/v1/articles:batch",
+			body: `[` + validArticle + `, {"guid": "guid 2"}]`, expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "articles[1].title", Code: "required", Message: "articles[1].title is required"},
+				{Field: "articles[1].description", Code: "required", Message: "articles[1].description is required"},
+				{Field: "articles[1].link", Code: "required", Message: "articles[1].link is required"},
+				{Field: "articles[1].published_date", Code: "required",
+					Message: "articles[1].published_date is required"},
+				{Field: "articles[1].provider", Code: "required", Message: "articles[1].provider is required"},
+				{Field: "articles[1].category", Code: "required", Message: "articles[1].category is required"}}},
+		"mistyped batch item property": {method: "POST", rawURL: "/api/v1/articles:batch",
+			body: `[` + validArticle + `, ` + validArticle + `, {"title": ["title"]}]`, expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "articles[2].title", Code: "type", Message: "articles[2].title must be a string"}}},
+		"invalid body": {method: "POST", rawURL: "/api/v1/webhooks",
+			body: `{"url": "not a url", "secret": "short"}`, expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "url", Code: "url", Message: "url must be a valid URL"},
+				{Field: "secret", Code: "min", Message: "secret must be at least 16 characters"}}},
+		"missing body property": {method: "POST", rawURL: "/api/v1/feeds",
+			body: `{"url": "https://provider.example/rss", "provider": "provider"}`, expectedStatus: 400,
+			expectedCode: api.CodeValidationFailed,
+			expectedErrors: []middleware.FieldError{
+				{Field: "category", Code: "required", Message: "category is required"}}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(test.method, test.rawURL, strings.NewReader(test.body))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, api.ProblemContentType, w.Header().Get("Content-Type"))
+
+			var problem api.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, http.StatusText(test.expectedStatus), problem.Title)
+			assert.Equal(t, test.expectedStatus, problem.Status)
+			assert.NotEmpty(t, problem.Detail)
+			assert.Equal(t, req.URL.Path, problem.Instance)
+			assert.Equal(t, test.expectedCode, problem.Code)
+			assert.Equal(t, w.Header().Get(middleware.RequestIDHeader), problem.RequestID)
+			assert.Equal(t, test.expectedErrors, problem.Errors)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+)
+
+// apiKeyPrefixLength is the number of leading characters of a key kept in the clear to tell keys apart.
+const apiKeyPrefixLength = 8
+
+// GetAPIKeys handles requests to get all API keys. Only their prefixes are returned, never the keys.
+func (s *Server) GetAPIKeys(c *gin.Context) {
+	keys, err := s.repo(c).GetAPIKeys()
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(200, keys)
+}
+
+// AddAPIKey handles requests to create an API key.
+// The key is generated and only its hash stored, so the response is the only time the key is returned.
+func (s *Server) AddAPIKey(c *gin.Context) {
+	bodyData := struct {
+		Label string `json:"label" binding:"required,max=100"`
+	}{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	key, err := generateSecret()
+	if err != nil {
+		s.logger(c).Error(fmt.Sprintf("error generating API key: %s", err.Error()))
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	apiKey, err := s.repo(c).AddAPIKey(entities.APIKey{
+		Label:     bodyData.Label,
+		Prefix:    key[:apiKeyPrefixLength],
+		Hash:      middleware.HashAPIKey(key),
+		Enabled:   true,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(201, struct {
+		entities.APIKey
+		Key string `json:"key"`
+	}{APIKey: apiKey, Key: key})
+}
+
+// UpdateAPIKey handles requests to enable or disable an API key.
+func (s *Server) UpdateAPIKey(c *gin.Context) {
+	id, ok := apiKeyID(c)
+	if !ok {
+		return
+	}
+
+	bodyData := struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	s.setAPIKeyEnabled(c, id, *bodyData.Enabled)
+}
+
+// RevokeAPIKey handles requests to revoke an API key.
+// The key is disabled rather than deleted so that it can still be identified in past logs.
+func (s *Server) RevokeAPIKey(c *gin.Context) {
+	id, ok := apiKeyID(c)
+	if !ok {
+		return
+	}
+
+	s.setAPIKeyEnabled(c, id, false)
+}
+
+// setAPIKeyEnabled enables or disables an API key and sends the response.
+func (s *Server) setAPIKeyEnabled(c *gin.Context, id uint64, enabled bool) {
+	err := s.repo(c).SetAPIKeyEnabled(id, enabled)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeAPIKeyNotFound, "API key not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.Status(204)
+}
+
+// apiKeyID parses the API key ID path parameter.
+// If it returns false, a response has already been sent.
+func apiKeyID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithInvalidField(c, "id", "API key id must be a positive integer")
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,204 @@
+package api_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	const adminKey = "admin-key-0123456789"
+	const validKey = "valid-key"
+	const revokedKey = "revoked-key"
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetAPIKeyByHash", middleware.HashAPIKey(validKey)).
+		Return(entities.APIKey{ID: 1, Label: "ingestion", Enabled: true}, nil)
+	mockDB.On("GetAPIKeyByHash", middleware.HashAPIKey(revokedKey)).
+		Return(entities.APIKey{ID: 2, Label: "old", Enabled: false}, nil)
+	mockDB.On("GetAPIKeyByHash", mock.Anything).Return(entities.APIKey{}, &repository.DBNotFoundError{})
+	mockDB.On("AddArticle", mock.Anything).Return(nil)
+	mockDB.On("GetArticlesVersion", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(entities.ArticlesVersion{}, nil)
+	mockDB.On("GetArticles", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(entities.Articles{}, nil)
+	mockDB.On("GetWebhooks").Return(entities.Webhooks{}, nil)
+	mockDB.On("GetAPIKeys").Return(entities.APIKeys{{ID: 1, Label: "ingestion", Prefix: "01234567", Hash: "secret"}}, nil)
+	mockDB.On("AddAPIKey", mock.Anything).Return(func(k entities.APIKey) entities.APIKey { k.ID = 3; return k }, nil)
+	mockDB.On("SetAPIKeyEnabled", uint64(1), false).Return(nil)
+	mockDB.On("SetAPIKeyEnabled", mock.Anything, mock.Anything).Return(&repository.DBNotFoundError{})
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithAPIKeyAuth(adminKey))
+	router := server.Router
+
+	article := `{"guid": "guid 9", "title": "title 9", "description": "description 9", "link": "https://link9.com",
+		"published_date": "2020-06-01T10:00:00Z", "provider": "provider 1", "category": "category 1"}`
+
+	tests := map[string]struct {
+		method             string
+		rawURL             string
+		key                string
+		body               string
+		expectedStatusCode int
+	}{
+		"read without key":        {method: "GET", rawURL: "/api/v1/articles", expectedStatusCode: 200},
+		"write without key":       {method: "POST", rawURL: "/api/v1/articles", body: article, expectedStatusCode: 401},
+		"write with unknown key":  {method: "POST", rawURL: "/api/v1/articles", key: "unknown", body: article, expectedStatusCode: 401},
+		"write with revoked key":  {method: "POST", rawURL: "/api/v1/articles", key: revokedKey, body: article, expectedStatusCode: 401},
+		"write with valid key":    {method: "POST", rawURL: "/api/v1/articles", key: validKey, body: article, expectedStatusCode: 204},
+		"webhooks without key":    {method: "GET", rawURL: "/api/v1/webhooks", expectedStatusCode: 401},
+		"webhooks with valid key": {method: "GET", rawURL: "/api/v1/webhooks", key: validKey, expectedStatusCode: 200},
+		"admin with api key":      {method: "GET", rawURL: "/api/v1/admin/apikeys", key: validKey, expectedStatusCode: 403},
+		"admin list":              {method: "GET", rawURL: "/api/v1/admin/apikeys", key: adminKey, expectedStatusCode: 200},
+		"admin create":            {method: "POST", rawURL: "/api/v1/admin/apikeys", key: adminKey, body: `{"label": "new"}`, expectedStatusCode: 201},
+		"admin create no label":   {method: "POST", rawURL: "/api/v1/admin/apikeys", key: adminKey, body: `{}`, expectedStatusCode: 400},
+		"admin revoke":            {method: "DELETE", rawURL: "/api/v1/admin/apikeys/1", key: adminKey, expectedStatusCode: 204},
+		"admin revoke not found":  {method: "DELETE", rawURL: "/api/v1/admin/apikeys/9", key: adminKey, expectedStatusCode: 404},
+		"admin update no enabled": {method: "PUT", rawURL: "/api/v1/admin/apikeys/1", key: adminKey, body: `{}`, expectedStatusCode: 400},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(test.method, test.rawURL, bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			if test.key != "" {
+				req.Header.Set(middleware.APIKeyHeader, test.key)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+		})
+	}
+
+	t.Run("created key is returned once and stored hashed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest("POST", "/api/v1/admin/apikeys", bytes.NewBufferString(`{"label": "new"}`))
+		require.NoError(t, err)
+		req.Header.Set(middleware.APIKeyHeader, adminKey)
+		router.ServeHTTP(w, req)
+		require.Equal(t, 201, w.Code)
+
+		response := struct {
+			ID     uint64 `json:"id"`
+			Key    string `json:"key"`
+			Prefix string `json:"prefix"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, uint64(3), response.ID)
+		assert.Equal(t, response.Key[:8], response.Prefix)
+
+		mockDB.AssertCalled(t, "AddAPIKey", mock.MatchedBy(func(k entities.APIKey) bool {
+			return k.Hash == middleware.HashAPIKey(response.Key) && k.Label == "new" && k.Enabled
+		}))
+	})
+
+	t.Run("admin endpoints disabled without admin key", func(t *testing.T) {
+		server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithAPIKeyAuth(""))
+
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/api/v1/admin/apikeys", nil)
+		require.NoError(t, err)
+		req.Header.Set(middleware.APIKeyHeader, "")
+		server.Router.ServeHTTP(w, req)
+
+		assert.Equal(t, 403, w.Code)
+	})
+}
+
+// hs256Token returns a JWT signed with an HMAC secret.
+func hs256Token(t *testing.T, secret string, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTAuth(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	mockDB := setupMockDB()
+	mockDB.On("AddArticle", mock.Anything).Return(nil)
+	mockDB.On("GetAPIKeys").Return(entities.APIKeys{}, nil)
+	mockDB.On("HealthCheck").Return(nil)
+
+	validator := middleware.NewJWTValidator("https://gateway.example", "articles-mgmt")
+	validator.AddHMACSecret("", []byte(secret))
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithJWTAuth(validator))
+	router := server.Router
+
+	token := func(scope string) string {
+		return hs256Token(t, secret, map[string]interface{}{
+			"sub":   "client",
+			"iss":   "https://gateway.example",
+			"aud":   "articles-mgmt",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"scope": scope,
+		})
+	}
+
+	article := `{"guid": "guid 9", "title": "title 9", "description": "description 9", "link": "https://link9.com",
+		"published_date": "2020-06-01T10:00:00Z", "provider": "provider 1", "category": "category 1"}`
+
+	tests := map[string]struct {
+		method             string
+		rawURL             string
+		authorization      string
+		body               string
+		expectedStatusCode int
+	}{
+		"health check without token": {method: "GET", rawURL: "/api/v1/healthcheck", expectedStatusCode: 200},
+		"read without token":         {method: "GET", rawURL: "/api/v1/articles", expectedStatusCode: 401},
+		"read with invalid token":    {method: "GET", rawURL: "/api/v1/articles", authorization: "Bearer abc", expectedStatusCode: 401},
+		"read with basic auth":       {method: "GET", rawURL: "/api/v1/articles", authorization: "Basic YWJjOmRlZg==", expectedStatusCode: 401},
+		"read with read scope":       {method: "GET", rawURL: "/api/v1/articles", authorization: "Bearer " + token("articles:read"), expectedStatusCode: 200},
+		"export with read scope":     {method: "GET", rawURL: "/api/v1/articles:export", authorization: "Bearer " + token("articles:read"), expectedStatusCode: 200},
+		"write with read scope":      {method: "POST", rawURL: "/api/v1/articles", authorization: "Bearer " + token("articles:read"), body: article, expectedStatusCode: 403},
+		"write with write scope":     {method: "POST", rawURL: "/api/v1/articles", authorization: "Bearer " + token("articles:write"), body: article, expectedStatusCode: 204},
+		"admin with write scope":     {method: "GET", rawURL: "/api/v1/admin/apikeys", authorization: "Bearer " + token("articles:write"), expectedStatusCode: 403},
+		"admin with admin scope":     {method: "GET", rawURL: "/api/v1/admin/apikeys", authorization: "Bearer " + token("admin"), expectedStatusCode: 200},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(test.method, test.rawURL, bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode == 401 || test.expectedStatusCode == 403 {
+				assert.Equal(t, api.ProblemContentType, w.Header().Get("Content-Type"))
+				assert.Contains(t, w.Body.String(), `"code"`)
+			}
+		})
+	}
+}
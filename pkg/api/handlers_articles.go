@@ -1,43 +1,89 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
 )
 
+// maxBatchSize is the maximum size of a decompressed batch of articles.
+const maxBatchSize = 64 << 20
+
+// articlesQuery holds the query parameters used to filter article listings.
+type articlesQuery struct {
+	Provider string     `form:"provider"`
+	Category string     `form:"category"`
+	Sorting  string     `form:"sorting"`
+	Limit    int        `form:"limit"`
+	After    *time.Time `form:"after"`
+}
+
 // GetArticles handles requests to get articles.
+// Besides JSON, articles can be requested as RSS 2.0 or Atom through the Accept header.
+// Responses can be revalidated with their ETag or Last-Modified (see respondCacheable).
 func (s *Server) GetArticles(c *gin.Context) {
-	queryParams := struct {
-		Provider string     `form:"provider"`
-		Category string     `form:"category"`
-		Sorting  string     `form:"sorting"`
-		Limit    int        `form:"limit"`
-		After    *time.Time `form:"after"`
-	}{
+	c.Writer.Header().Add("Vary", "Accept")
+
+	switch c.NegotiateFormat(gin.MIMEJSON, "application/rss+xml", "application/atom+xml") {
+	case "application/rss+xml":
+		s.renderFeed(c, feed.RSSContentType, feed.WriteRSS)
+	case "application/atom+xml":
+		s.renderFeed(c, feed.AtomContentType, feed.WriteAtom)
+	default:
+		const contentType = "application/json; charset=utf-8"
+		articles, etag, ok := s.getArticles(c, contentType)
+		if !ok {
+			return
+		}
+
+		body, err := json.Marshal(articles)
+		if err != nil {
+			s.logger(c).Error(fmt.Sprintf("error rendering articles: %s", err.Error()))
+			RespondWithError(c, 500, CodeInternalError, "Internal error")
+			return
+		}
+
+		s.respondCacheable(c, contentType, body, etag, lastModified(articles))
+	}
+}
+
+// getArticles parses the article listing query parameters and fetches the matching articles, along with the
+// ETag of their listing as contentType (see listingETag).
+// Requests whose If-None-Match matches the ETag get a 304 without the articles being fetched.
+// If it returns false, a response has already been sent.
+func (s *Server) getArticles(c *gin.Context, contentType string) (entities.Articles, string, bool) {
+	queryParams := articlesQuery{
 		Sorting: "desc",
 		Limit:   50,
 	}
 
 	if err := c.ShouldBindQuery(&queryParams); err != nil {
-		s.Logger.Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
-		RespondWithError(c, 400, err.Error())
-		return
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return nil, "", false
 	}
 
 	// Sorting can only be 'asc' or 'desc'
 	if queryParams.Sorting != "asc" && queryParams.Sorting != "desc" {
-		RespondWithError(c, 400, "sorting query parameter can only take one of two values: 'asc' or 'desc'")
-		return
+		RespondWithInvalidField(c, "sorting",
+			"sorting query parameter can only take one of two values: 'asc' or 'desc'")
+		return nil, "", false
 	}
 
 	// Limit can have a max of 200
 	if queryParams.Limit < 1 || queryParams.Limit > 200 {
-		RespondWithError(c, 400, "limit query parameter can be a minimum of 1 and a maximum of 200")
-		return
+		RespondWithInvalidField(c, "limit", "limit query parameter can be a minimum of 1 and a maximum of 200")
+		return nil, "", false
 	}
 
 	// Make timezone UTC
@@ -46,106 +92,186 @@ func (s *Server) GetArticles(c *gin.Context) {
 		queryParams.After = &tempAfter
 	}
 
-	articles, err := s.Repo.GetArticles(queryParams.Provider, queryParams.Category,
+	// Checking the version of the articles first spares getting them when the client's copy is current
+	version, err := s.repo(c).GetArticlesVersion(queryParams.Provider, queryParams.Category,
+		queryParams.Sorting, queryParams.After)
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return nil, "", false
+	}
+
+	etag := listingETag(c, contentType, version)
+	if notModified(c.Request, etag, time.Time{}) {
+		s.respondNotModified(c, etag)
+		return nil, "", false
+	}
+
+	articles, err := s.repo(c).GetArticles(queryParams.Provider, queryParams.Category,
 		queryParams.Sorting, queryParams.Limit, queryParams.After)
 	if err != nil {
-		s.Logger.Error(err.Error())
-		RespondWithError(c, 500, "Internal error")
-		return
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return nil, "", false
 	}
 
-	c.JSON(200, articles)
+	return articles, etag, true
 }
 
 // AddArticle handles requests to add an article.
 func (s *Server) AddArticle(c *gin.Context) {
-	bodyData := struct {
-		GUID          string    `json:"guid" binding:"required"`
-		Title         string    `json:"title" binding:"required"`
-		Description   string    `json:"description" binding:"required"`
-		Link          string    `json:"link" binding:"required"`
-		PublishedTime time.Time `json:"published_date" binding:"required"`
-		Provider      string    `json:"provider" binding:"required"`
-		Category      string    `json:"category" binding:"required"`
-	}{}
+	bodyData := entities.ArticleInput{}
 
 	err := c.ShouldBindJSON(&bodyData)
 	if err != nil {
-		s.Logger.Info(fmt.Sprintf("error parsing body: %s", err.Error()))
-		RespondWithError(c, 400, err.Error())
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
 		return
 	}
 
-	// Make timezone UTC
-	bodyData.PublishedTime = bodyData.PublishedTime.UTC()
-
-	article := entities.Article{
-		GUID:          bodyData.GUID,
-		Title:         bodyData.Title,
-		Description:   bodyData.Description,
-		Link:          bodyData.Link,
-		PublishedTime: bodyData.PublishedTime,
-		Provider:      bodyData.Provider,
-		Category:      bodyData.Category,
-	}
+	article := bodyData.Article()
 
-	err = s.Repo.AddArticle(article)
+	err = s.repo(c).AddArticle(article)
 	if errT, ok := err.(*repository.DBDUPError); ok {
-		s.Logger.Error(errT.Error())
-		RespondWithError(c, 409, "article GUID already exists in the database")
+		s.metrics.ArticleIngested(metrics.SourceAPI, metrics.ResultDuplicate)
+		s.logger(c).Error(errT.Error())
+		RespondWithError(c, 409, CodeArticleExists, "article GUID already exists in the database")
 		return
 	} else if err != nil {
-		s.Logger.Error(err.Error())
-		RespondWithError(c, 500, "Internal error")
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
 		return
 	}
 
+	s.metrics.ArticleIngested(metrics.SourceAPI, metrics.ResultCreated)
+
 	c.Status(204)
 }
 
 // AddArticles handles requests to add multiple articles.
 func (s *Server) AddArticles(c *gin.Context) {
-	bodyData := []struct {
-		GUID          string    `json:"guid" binding:"required"`
-		Title         string    `json:"title" binding:"required"`
-		Description   string    `json:"description" binding:"required"`
-		Link          string    `json:"link" binding:"required"`
-		PublishedTime time.Time `json:"published_date" binding:"required"`
-		Provider      string    `json:"provider" binding:"required"`
-		Category      string    `json:"category" binding:"required"`
-	}{}
+	bodyData := articleBatch{}
 
 	err := c.ShouldBindJSON(&bodyData)
+	if err == nil {
+		err = bodyData.validate()
+	}
 	if err != nil {
-		s.Logger.Info(fmt.Sprintf("error parsing body: %s", err.Error()))
-		RespondWithError(c, 400, err.Error())
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		respondWithBatchError(c, err)
 		return
 	}
 
 	// convert into entities.Article
 	for _, item := range bodyData {
-		// Make timezone UTC
-		item.PublishedTime = item.PublishedTime.UTC()
-
-		article := entities.Article{
-			GUID:          item.GUID,
-			Title:         item.Title,
-			Description:   item.Description,
-			Link:          item.Link,
-			PublishedTime: item.PublishedTime,
-			Provider:      item.Provider,
-			Category:      item.Category,
-		}
+		article := item.Article()
 
-		err = s.Repo.AddArticle(article)
+		err = s.repo(c).AddArticle(article)
 		if _, ok := err.(*repository.DBDUPError); ok {
+			s.metrics.ArticleIngested(metrics.SourceAPI, metrics.ResultDuplicate)
 			continue
 		} else if err != nil {
-			s.Logger.Error(err.Error())
-			RespondWithError(c, 500, "Internal error")
+			s.logger(c).Error(err.Error())
+			RespondWithError(c, 500, CodeInternalError, "Internal error")
 			return
 		}
+
+		s.metrics.ArticleIngested(metrics.SourceAPI, metrics.ResultCreated)
 	}
 
 	c.Status(204)
 }
+
+// articleBatch is the body of batch requests. Its items are decoded and validated one by one, so that errors
+// can tell which item they're about.
+type articleBatch []entities.ArticleInput
+
+// batchItemError is an error decoding or validating an item of a batch.
+type batchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *batchItemError) Error() string {
+	return fmt.Sprintf("articles[%d]: %s", e.Index, e.Err.Error())
+}
+
+func (e *batchItemError) Unwrap() error {
+	return e.Err
+}
+
+// UnmarshalJSON decodes the items of the batch.
+func (b *articleBatch) UnmarshalJSON(data []byte) error {
+	var rawItems []json.RawMessage
+	if err := json.Unmarshal(data, &rawItems); err != nil {
+		return err
+	}
+
+	*b = make(articleBatch, len(rawItems))
+	for i, rawItem := range rawItems {
+		if err := json.Unmarshal(rawItem, &(*b)[i]); err != nil {
+			return &batchItemError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// validate validates the items of the batch with their validation rules, which gin doesn't do for slices.
+// It returns the errors of all the invalid items.
+func (b articleBatch) validate() error {
+	var errs batchErrors
+	for i := range b {
+		if err := binding.Validator.ValidateStruct(&b[i]); err != nil {
+			errs = append(errs, &batchItemError{Index: i, Err: err})
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// batchErrors lists the errors of the items of a batch.
+type batchErrors []*batchItemError
+
+func (e batchErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// respondWithBatchError responds to a batch request that couldn't be bound or validated with a 400, naming the
+// invalid fields after the index of their item, e.g. 'articles[3].title'.
+func respondWithBatchError(c *gin.Context, err error) {
+	var errs batchErrors
+	if !errors.As(err, &errs) {
+		var itemError *batchItemError
+		if !errors.As(err, &itemError) {
+			RespondWithBindingError(c, CodeInvalidBody, err)
+			return
+		}
+		errs = batchErrors{itemError}
+	}
+
+	var fieldErrors []middleware.FieldError
+	for _, itemError := range errs {
+		itemFieldErrors := bindingFieldErrors(c, itemError.Err)
+		if len(itemFieldErrors) == 0 {
+			RespondWithError(c, 400, CodeInvalidBody, itemError.Error())
+			return
+		}
+
+		for _, fieldError := range itemFieldErrors {
+			// Messages start with the field name
+			path := fmt.Sprintf("articles[%d].", itemError.Index)
+			fieldError.Field = path + fieldError.Field
+			fieldError.Message = path + fieldError.Message
+			fieldErrors = append(fieldErrors, fieldError)
+		}
+	}
+
+	respondWithFieldErrors(c, fieldErrors)
+}
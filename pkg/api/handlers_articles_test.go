@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sort"
 	"strconv"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/stats"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -151,5 +153,147 @@ func setupMockDB() *mocks.Repository {
 	call = call.On("GetArticles", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 	call = call.Return(mockGetArticlesFn, nil)
 
+	// GetArticlesVersion mock ------------------------------
+	mockGetArticlesVersionFn := func(provider string, category string, sorting string, after *time.Time) (version entities.ArticlesVersion) {
+		for _, article := range mockGetArticlesFn(provider, category, sorting, 0, after) {
+			version.Count++
+			if article.PublishedTime.After(version.LastPublished) {
+				version.LastPublished = article.PublishedTime
+			}
+		}
+		return version
+	}
+	call = call.On("GetArticlesVersion", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	call = call.Return(mockGetArticlesVersionFn, nil)
+
+	// StreamArticles mock ----------------------------------
+	// Articles are sorted by published date and GUID, and resumed after the cursor, like the database does
+	mockStreamArticlesFn := func(provider string, category string, after *time.Time, afterGUID string, fn func(entities.Article) error) error {
+		articles := mockGetArticlesFn(provider, category, "asc", 0, nil)
+		sort.Slice(articles, func(i, j int) bool {
+			if !articles[i].PublishedTime.Equal(articles[j].PublishedTime) {
+				return articles[i].PublishedTime.Before(articles[j].PublishedTime)
+			}
+			return articles[i].GUID < articles[j].GUID
+		})
+
+		for _, item := range articles {
+			if after != nil && (item.PublishedTime.Before(*after) ||
+				item.PublishedTime.Equal(*after) && (afterGUID == "" || item.GUID <= afterGUID)) {
+				continue
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	call = call.On("StreamArticles", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	call = call.Return(mockStreamArticlesFn)
+
+	// GetArticleStats mock ---------------------------------
+	mockGetArticleStatsFn := func(interval string, byProvider bool, byCategory bool, from time.Time, to time.Time) entities.ArticleCounts {
+		return stats.Aggregate(data, interval, byProvider, byCategory, from, to)
+	}
+
+	call = call.On("GetArticleStats", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	call = call.Return(mockGetArticleStatsFn, nil)
+
 	return mockDB
 }
+
+func TestGetArticlesConditional(t *testing.T) {
+	server := api.NewServer("", 9999, false, log.NullLogger{}, setupMockDB(),
+		api.WithCacheControl(map[string]string{"/api/v1/articles": "no-cache", "/api/v1/articles.rss": "max-age=60"}))
+	router := server.Router
+
+	get := func(rawURL string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", rawURL, nil)
+		require.NoError(t, err)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/api/v1/articles", nil)
+	require.Equal(t, 200, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	// The newest article of GenData
+	assert.Equal(t, "Sat, 10 Oct 2020 12:30:00 GMT", w.Header().Get("Last-Modified"))
+	assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	tests := map[string]struct {
+		rawURL         string
+		headers        map[string]string
+		expectedStatus int
+	}{
+		"matching etag":          {rawURL: "/api/v1/articles", headers: map[string]string{"If-None-Match": etag}, expectedStatus: 304},
+		"matching weak etag":     {rawURL: "/api/v1/articles", headers: map[string]string{"If-None-Match": `"abc", W/` + etag}, expectedStatus: 304},
+		"wildcard etag":          {rawURL: "/api/v1/articles", headers: map[string]string{"If-None-Match": "*"}, expectedStatus: 304},
+		"other etag":             {rawURL: "/api/v1/articles", headers: map[string]string{"If-None-Match": `"abc"`}, expectedStatus: 200},
+		"other filters":          {rawURL: "/api/v1/articles?provider=provider%201", headers: map[string]string{"If-None-Match": etag}, expectedStatus: 200},
+		"other format":           {rawURL: "/api/v1/articles.rss", headers: map[string]string{"If-None-Match": etag}, expectedStatus: 200},
+		"not modified since":     {rawURL: "/api/v1/articles", headers: map[string]string{"If-Modified-Since": "Sat, 10 Oct 2020 12:30:00 GMT"}, expectedStatus: 304},
+		"modified since":         {rawURL: "/api/v1/articles", headers: map[string]string{"If-Modified-Since": "Sat, 10 Oct 2020 12:29:59 GMT"}, expectedStatus: 200},
+		"etag takes precedence":  {rawURL: "/api/v1/articles", headers: map[string]string{"If-None-Match": `"abc"`, "If-Modified-Since": "Sat, 10 Oct 2020 12:30:00 GMT"}, expectedStatus: 200},
+		"invalid modified since": {rawURL: "/api/v1/articles", headers: map[string]string{"If-Modified-Since": "yesterday"}, expectedStatus: 200},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := get(test.rawURL, test.headers)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.NotEmpty(t, w.Header().Get("ETag"))
+			if test.expectedStatus == 304 {
+				assert.Empty(t, w.Body.String())
+				assert.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+
+	w = get("/api/v1/articles.rss", nil)
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"))
+	assert.Equal(t, 304, get("/api/v1/articles.rss", map[string]string{"If-None-Match": w.Header().Get("ETag")}).Code)
+
+	t.Run("articles not fetched when the etag matches", func(t *testing.T) {
+		version := entities.ArticlesVersion{Count: 1, LastPublished: time.Date(2020, 10, 10, 12, 30, 0, 0, time.UTC)}
+		mockDB := &mocks.Repository{}
+		mockDB.On("GetArticlesVersion", "", "", "desc", (*time.Time)(nil)).
+			Return(func(string, string, string, *time.Time) entities.ArticlesVersion { return version }, nil)
+		mockDB.On("GetArticles", "", "", "desc", 50, (*time.Time)(nil)).Return(entities.Articles{}, nil)
+		router := api.NewServer("", 9999, false, log.NullLogger{}, mockDB).Router
+
+		get := func(etag string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/api/v1/articles", nil)
+			require.NoError(t, err)
+			req.Header.Set("If-None-Match", etag)
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := get(`"abc"`)
+		require.Equal(t, 200, w.Code)
+		etag := w.Header().Get("ETag")
+
+		w = get(etag)
+		assert.Equal(t, 304, w.Code)
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+		mockDB.AssertNumberOfCalls(t, "GetArticles", 1)
+
+		// An article was added
+		version.Count++
+		w = get(etag)
+		assert.Equal(t, 200, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		mockDB.AssertNumberOfCalls(t, "GetArticles", 2)
+	})
+}
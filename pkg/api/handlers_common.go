@@ -4,26 +4,58 @@ import (
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/health"
 )
 
 // NoRoute provides a generic handler for unmatched routes.
 func NoRoute(c *gin.Context) {
-	c.JSON(404, gin.H{"message": "no route found"})
-}
-
-// RespondWithError is a helper function to return an error according to the API specification.
-func RespondWithError(c *gin.Context, httpCode int, message string) {
-	c.JSON(httpCode, gin.H{"message": message})
+	RespondWithError(c, 404, CodeRouteNotFound, "no route found")
 }
 
 // Healthcheck checks health of the service.
 func (s *Server) Healthcheck(c *gin.Context) {
-	err := s.Repo.HealthCheck()
+	err := s.repo(c).HealthCheck()
 	if err != nil {
-		s.Logger.Error(fmt.Sprintf("database health check error: %s", err.Error()))
+		s.logger(c).Error(fmt.Sprintf("database health check error: %s", err.Error()))
 		c.JSON(500, gin.H{"status": "FAIL"})
 		return
 	}
 
 	c.JSON(200, gin.H{"status": "OK"})
 }
+
+// Livez answers liveness probes: the service is live as long as it answers, whatever the state of its
+// dependencies (as of their last check, which is reported to admins).
+func (s *Server) Livez(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(200, healthResponse(c, s.Health.Live()))
+}
+
+// Readyz answers readiness probes: the service is ready when all of its dependencies are up and it's not
+// shutting down. The errors of the dependencies that are down are logged.
+func (s *Server) Readyz(c *gin.Context) {
+	report := s.Health.Ready()
+	c.Header("Cache-Control", "no-store")
+	if report.Status != health.StatusUp {
+		for _, dependency := range report.Dependencies {
+			if dependency.Status != health.StatusUp {
+				s.logger(c).Warn(fmt.Sprintf("%s health check error: %s", dependency.Name, dependency.Error))
+			}
+		}
+		c.JSON(503, healthResponse(c, report))
+		return
+	}
+
+	c.JSON(200, healthResponse(c, report))
+}
+
+// healthResponse returns what a probe responds with: the whole health report for admins, only the status for
+// other clients, as dependency errors and connection pool statistics tell about the infrastructure.
+func healthResponse(c *gin.Context, report health.Report) interface{} {
+	if identity, ok := middleware.GetIdentity(c); ok && identity.HasScope(middleware.ScopeAdmin) {
+		return report
+	}
+
+	return gin.H{"status": report.Status}
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// exportFlushEvery is the number of articles written between flushes of the response.
+const exportFlushEvery = 100
+
+// ExportArticles handles requests to export articles.
+//
+// Articles are streamed sorted by published date and GUID (ascending), either as newline-delimited JSON
+// (default) or CSV. An interrupted export can be resumed by passing the published date and GUID of the last
+// article received in the 'after' and 'after_guid' query parameters.
+func (s *Server) ExportArticles(c *gin.Context) {
+	queryParams := struct {
+		Provider  string     `form:"provider"`
+		Category  string     `form:"category"`
+		Format    string     `form:"format"`
+		After     *time.Time `form:"after"`
+		AfterGUID string     `form:"after_guid"`
+	}{
+		Format: "ndjson",
+	}
+
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return
+	}
+
+	// Format can only be 'ndjson' or 'csv'
+	if queryParams.Format != "ndjson" && queryParams.Format != "csv" {
+		RespondWithInvalidField(c, "format",
+			"format query parameter can only take one of two values: 'ndjson' or 'csv'")
+		return
+	}
+
+	if queryParams.AfterGUID != "" && queryParams.After == nil {
+		RespondWithInvalidField(c, "after_guid", "after_guid query parameter requires the after query parameter")
+		return
+	}
+
+	// Make timezone UTC
+	if queryParams.After != nil {
+		tempAfter := queryParams.After.UTC()
+		queryParams.After = &tempAfter
+	}
+
+	// Large exports take longer than the server's write timeout
+	if err := clearWriteDeadline(c); err != nil {
+		s.logger(c).Warn(fmt.Sprintf("error clearing write deadline: %s", err.Error()))
+	}
+
+	if queryParams.Format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/x-ndjson")
+	}
+
+	// Responses are compressed here rather than by the compression middleware, which may be off, so that the
+	// compressed stream is flushed along the way
+	c.Writer.Header().Add("Vary", "Accept-Encoding")
+	var w io.Writer = c.Writer
+	var encoder middleware.Encoder
+	if encoding := middleware.NegotiateEncoding(c.GetHeader("Accept-Encoding")); encoding != "" {
+		c.Header("Content-Encoding", encoding)
+		encoder = middleware.NewEncoder(c.Writer, encoding)
+		defer encoder.Close()
+		w = encoder
+	}
+
+	c.Status(200)
+
+	encode := newArticleEncoder(w, queryParams.Format)
+	count := 0
+
+	err := s.repo(c).StreamArticles(queryParams.Provider, queryParams.Category, queryParams.After, queryParams.AfterGUID,
+		func(article entities.Article) error {
+			if err := encode(&article); err != nil {
+				return err
+			}
+
+			count++
+			if count%exportFlushEvery == 0 {
+				if encoder != nil {
+					if err := encoder.Flush(); err != nil {
+						return err
+					}
+				}
+				c.Writer.Flush()
+			}
+
+			return nil
+		})
+	if err != nil {
+		// Headers have already been sent, all we can do is cut the stream short.
+		s.logger(c).Error(fmt.Sprintf("error exporting articles: %s", err.Error()))
+		c.Abort()
+		return
+	}
+
+	if err := encode(nil); err != nil {
+		s.logger(c).Error(fmt.Sprintf("error exporting articles: %s", err.Error()))
+	}
+}
+
+// newArticleEncoder returns a function that writes articles to w in the given format.
+// Calling it with a nil article flushes any buffered data.
+func newArticleEncoder(w io.Writer, format string) func(article *entities.Article) error {
+	if format == "csv" {
+		csvWriter := csv.NewWriter(w)
+		headerWritten := false
+
+		return func(article *entities.Article) error {
+			if !headerWritten {
+				headerWritten = true
+				err := csvWriter.Write([]string{"guid", "title", "description", "link", "published_date", "provider", "category"})
+				if err != nil {
+					return err
+				}
+			}
+
+			if article == nil {
+				csvWriter.Flush()
+				return csvWriter.Error()
+			}
+
+			err := csvWriter.Write([]string{article.GUID, article.Title, article.Description, article.Link,
+				article.PublishedTime.UTC().Format(time.RFC3339Nano), article.Provider, article.Category})
+			if err != nil {
+				return err
+			}
+
+			// Hand over to the underlying writer so that periodic flushes reach the client.
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	return func(article *entities.Article) error {
+		if article == nil {
+			return nil
+		}
+		return encoder.Encode(article)
+	}
+}
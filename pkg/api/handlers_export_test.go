@@ -0,0 +1,205 @@
+package api_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportArticlesHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := log.NullLogger{}
+	mockDB := setupMockDB()
+	server := api.NewServer("", 9999, false, logger, mockDB)
+	router := server.Router
+
+	tests := map[string]struct {
+		rawURL             string
+		acceptEncoding     string
+		expectedStatusCode int
+		expectedEncoding   string
+		expectedRows       int
+		expectedGUIDs      []string
+	}{
+		"invalid format": {
+			rawURL:             "/api/v1/articles:export?format=xml",
+			expectedStatusCode: 400,
+		},
+		"after guid without after": {
+			rawURL:             "/api/v1/articles:export?after_guid=guid%201",
+			expectedStatusCode: 400,
+		},
+		"ndjson": {
+			rawURL:             "/api/v1/articles:export",
+			expectedStatusCode: 200,
+			expectedRows:       3,
+			expectedGUIDs:      []string{"guid 3", "guid 1", "guid 2"},
+		},
+		"ndjson resumed after date": {
+			rawURL:             "/api/v1/articles:export?after=2020-05-10T12:30:00Z",
+			expectedStatusCode: 200,
+			expectedRows:       1,
+			expectedGUIDs:      []string{"guid 2"},
+		},
+		"ndjson resumed after date and guid": {
+			rawURL:             "/api/v1/articles:export?after=2020-05-10T12:30:00Z&after_guid=guid%200",
+			expectedStatusCode: 200,
+			expectedRows:       2,
+			expectedGUIDs:      []string{"guid 1", "guid 2"},
+		},
+		"ndjson resumed after last article": {
+			rawURL:             "/api/v1/articles:export?after=2020-10-10T12:30:00Z&after_guid=guid%202",
+			expectedStatusCode: 200,
+			expectedRows:       0,
+			expectedGUIDs:      []string{},
+		},
+		"ndjson filtered by provider": {
+			rawURL:             "/api/v1/articles:export?provider=provider%202",
+			expectedStatusCode: 200,
+			expectedRows:       1,
+		},
+		"ndjson gzipped": {
+			rawURL:             "/api/v1/articles:export",
+			acceptEncoding:     "gzip",
+			expectedStatusCode: 200,
+			expectedEncoding:   "gzip",
+			expectedRows:       3,
+		},
+		"ndjson brotli preferred": {
+			rawURL:             "/api/v1/articles:export",
+			acceptEncoding:     "gzip;q=0.5, br",
+			expectedStatusCode: 200,
+			expectedEncoding:   "br",
+			expectedRows:       3,
+		},
+		"ndjson gzip refused": {
+			rawURL:             "/api/v1/articles:export",
+			acceptEncoding:     "gzip;q=0",
+			expectedStatusCode: 200,
+			expectedRows:       3,
+		},
+		"csv": {
+			rawURL:             "/api/v1/articles:export?format=csv",
+			expectedStatusCode: 200,
+			expectedRows:       4,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", test.rawURL, nil)
+			require.NoError(t, err)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode != 200 {
+				return
+			}
+
+			assert.Equal("Accept-Encoding", w.Header().Get("Vary"))
+			assert.Equal(test.expectedEncoding, w.Header().Get("Content-Encoding"))
+
+			var body io.Reader = w.Body
+			switch test.expectedEncoding {
+			case "gzip":
+				body, err = gzip.NewReader(w.Body)
+				require.NoError(t, err)
+			case "br":
+				body = brotli.NewReader(w.Body)
+			}
+
+			rows := 0
+			guids := []string{}
+			if w.Header().Get("Content-Type") == "application/x-ndjson" {
+				scanner := bufio.NewScanner(body)
+				for scanner.Scan() {
+					var article entities.Article
+					require.NoError(t, json.Unmarshal(scanner.Bytes(), &article))
+					guids = append(guids, article.GUID)
+					rows++
+				}
+			} else {
+				records, err := csv.NewReader(body).ReadAll()
+				require.NoError(t, err)
+				rows = len(records)
+			}
+
+			assert.Equal(test.expectedRows, rows)
+			if test.expectedGUIDs != nil {
+				assert.Equal(test.expectedGUIDs, guids)
+			}
+		})
+	}
+}
+
+func TestExportArticlesCSVDates(t *testing.T) {
+	published := time.Date(2020, 5, 10, 12, 30, 0, 123456789, time.UTC)
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("StreamArticles", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(func(provider string, category string, after *time.Time, afterGUID string, fn func(entities.Article) error) error {
+			return fn(entities.Article{GUID: "guid 1", PublishedTime: published})
+		})
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/v1/articles:export?format=csv", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(w, req)
+
+	// Dates keep their fractional seconds, which exports are resumed with
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "2020-05-10T12:30:00.123456789Z", records[1][4])
+}
+
+func TestExportArticlesOutlastsWriteTimeout(t *testing.T) {
+	mockDB := &mocks.Repository{}
+	mockDB.On("StreamArticles", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(func(provider string, category string, after *time.Time, afterGUID string, fn func(entities.Article) error) error {
+			for _, article := range GenData() {
+				time.Sleep(100 * time.Millisecond)
+				if err := fn(article); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+	ts := httptest.NewUnstartedServer(server.HTTPServer.Handler)
+	ts.Config.WriteTimeout = 150 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/articles:export")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 3, bytes.Count(body, []byte("\n")))
+}
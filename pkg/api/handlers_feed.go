@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+)
+
+// GetArticlesRSS handles requests to get articles as an RSS 2.0 feed.
+// It takes the same query parameters as GetArticles.
+func (s *Server) GetArticlesRSS(c *gin.Context) {
+	s.renderFeed(c, feed.RSSContentType, feed.WriteRSS)
+}
+
+// GetArticlesAtom handles requests to get articles as an Atom feed.
+// It takes the same query parameters as GetArticles.
+func (s *Server) GetArticlesAtom(c *gin.Context) {
+	s.renderFeed(c, feed.AtomContentType, feed.WriteAtom)
+}
+
+// renderFeed gets the articles requested and renders them with the given feed writer, as a response that can be
+// revalidated.
+func (s *Server) renderFeed(c *gin.Context, contentType string,
+	write func(io.Writer, feed.Metadata, entities.Articles) error) {
+
+	articles, etag, ok := s.getArticles(c, contentType)
+	if !ok {
+		return
+	}
+
+	meta := feed.Metadata{
+		Title:       "News App Articles",
+		Description: "Articles curated by the News App",
+		Link:        requestURL(c),
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, meta, articles); err != nil {
+		s.logger(c).Error(fmt.Sprintf("error rendering feed: %s", err.Error()))
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	s.respondCacheable(c, contentType, buf.Bytes(), etag, lastModified(articles))
+}
+
+// requestURL reconstructs the absolute URL of the request.
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, c.Request.URL.RequestURI())
+}
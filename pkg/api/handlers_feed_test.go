@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedHandlers(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := log.NullLogger{}
+	mockDB := setupMockDB()
+	server := api.NewServer("", 9999, false, logger, mockDB)
+	router := server.Router
+
+	tests := map[string]struct {
+		rawURL              string
+		accept              string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedEntries     int
+	}{
+		"rss": {
+			rawURL:              "/api/v1/articles.rss",
+			expectedStatusCode:  200,
+			expectedContentType: feed.RSSContentType,
+			expectedEntries:     3,
+		},
+		"atom filtered by category": {
+			rawURL:              "/api/v1/articles.atom?category=category%201",
+			expectedStatusCode:  200,
+			expectedContentType: feed.AtomContentType,
+			expectedEntries:     1,
+		},
+		"rss invalid limit": {
+			rawURL:             "/api/v1/articles.rss?limit=500",
+			expectedStatusCode: 400,
+		},
+		"atom via accept header": {
+			rawURL:              "/api/v1/articles",
+			accept:              "application/atom+xml",
+			expectedStatusCode:  200,
+			expectedContentType: feed.AtomContentType,
+			expectedEntries:     3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", test.rawURL, nil)
+			require.NoError(t, err)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode != 200 {
+				return
+			}
+
+			assert.Equal(test.expectedContentType, w.Header().Get("Content-Type"))
+
+			if test.expectedContentType == feed.RSSContentType {
+				var doc feed.RSS
+				require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+				assert.Equal("2.0", doc.Version)
+				assert.Len(doc.Channel.Items, test.expectedEntries)
+			} else {
+				var doc feed.Atom
+				require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+				assert.Len(doc.Entries, test.expectedEntries)
+			}
+		})
+	}
+}
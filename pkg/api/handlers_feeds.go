@@ -0,0 +1,165 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+)
+
+// FeedBody represents a feed source in the body of write requests, along with its validation rules.
+type FeedBody struct {
+	URL      string `json:"url" binding:"required,url,max=500"`
+	Provider string `json:"provider" binding:"required,max=30"`
+	Category string `json:"category" binding:"required,max=30"`
+	// PollIntervalSeconds of 0 means the poller's default interval.
+	PollIntervalSeconds int   `json:"poll_interval_seconds" binding:"omitempty,min=60"`
+	Enabled             *bool `json:"enabled"`
+}
+
+// Validate validates the feed body with the same rules applied when binding requests.
+func (f FeedBody) Validate() error {
+	return binding.Validator.ValidateStruct(f)
+}
+
+// Feed converts the body into an entities.Feed. Feeds are enabled unless stated otherwise.
+func (f FeedBody) Feed(id uint64) entities.Feed {
+	enabled := true
+	if f.Enabled != nil {
+		enabled = *f.Enabled
+	}
+
+	return entities.Feed{
+		ID:                  id,
+		URL:                 f.URL,
+		Provider:            f.Provider,
+		Category:            f.Category,
+		PollIntervalSeconds: f.PollIntervalSeconds,
+		Enabled:             enabled,
+	}
+}
+
+// GetFeeds handles requests to get all feed sources.
+func (s *Server) GetFeeds(c *gin.Context) {
+	feeds, err := s.repo(c).GetFeeds()
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(200, feeds)
+}
+
+// GetFeed handles requests to get a feed source.
+func (s *Server) GetFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		return
+	}
+
+	feed, err := s.repo(c).GetFeed(id)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeFeedNotFound, "feed not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(200, feed)
+}
+
+// AddFeed handles requests to add a feed source.
+func (s *Server) AddFeed(c *gin.Context) {
+	bodyData := FeedBody{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	feed, err := s.repo(c).AddFeed(bodyData.Feed(0))
+	if errT, ok := err.(*repository.DBDUPError); ok {
+		s.logger(c).Error(errT.Error())
+		RespondWithError(c, 409, CodeFeedExists, "feed URL already exists in the database")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(201, feed)
+}
+
+// UpdateFeed handles requests to update a feed source.
+func (s *Server) UpdateFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		return
+	}
+
+	bodyData := FeedBody{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	err = s.repo(c).UpdateFeed(bodyData.Feed(id))
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeFeedNotFound, "feed not found")
+		return
+	} else if errT, ok := err.(*repository.DBDUPError); ok {
+		s.logger(c).Error(errT.Error())
+		RespondWithError(c, 409, CodeFeedExists, "feed URL already exists in the database")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.Status(204)
+}
+
+// DeleteFeed handles requests to delete a feed source.
+func (s *Server) DeleteFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		return
+	}
+
+	err := s.repo(c).DeleteFeed(id)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeFeedNotFound, "feed not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.Status(204)
+}
+
+// feedID parses the feed ID path parameter.
+// If it returns false, a response has already been sent.
+func feedID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithInvalidField(c, "id", "feed id must be a positive integer")
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedsHandlers(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := log.NullLogger{}
+	mockDB := &mocks.Repository{}
+	server := api.NewServer("", 9999, false, logger, mockDB)
+	router := server.Router
+
+	feed := entities.Feed{ID: 1, URL: "https://provider.example/rss", Provider: "provider", Category: "category",
+		Enabled: true}
+
+	mockDB.On("GetFeeds").Return(entities.Feeds{feed}, nil)
+	mockDB.On("GetFeed", uint64(1)).Return(feed, nil)
+	mockDB.On("GetFeed", mock.Anything).Return(entities.Feed{}, &repository.DBNotFoundError{})
+	mockDB.On("AddFeed", mock.MatchedBy(func(f entities.Feed) bool { return f.URL == feed.URL })).
+		Return(entities.Feed{}, &repository.DBDUPError{})
+	mockDB.On("AddFeed", mock.Anything).Return(func(f entities.Feed) entities.Feed { f.ID = 2; return f }, nil)
+	mockDB.On("UpdateFeed", mock.MatchedBy(func(f entities.Feed) bool { return f.ID == 1 && !f.Enabled })).Return(nil)
+	mockDB.On("UpdateFeed", mock.Anything).Return(&repository.DBNotFoundError{})
+	mockDB.On("DeleteFeed", uint64(1)).Return(nil)
+	mockDB.On("DeleteFeed", mock.Anything).Return(&repository.DBNotFoundError{})
+
+	tests := map[string]struct {
+		method             string
+		rawURL             string
+		body               string
+		expectedStatusCode int
+	}{
+		"list":                  {method: "GET", rawURL: "/api/v1/feeds", expectedStatusCode: 200},
+		"get":                   {method: "GET", rawURL: "/api/v1/feeds/1", expectedStatusCode: 200},
+		"get not found":         {method: "GET", rawURL: "/api/v1/feeds/9", expectedStatusCode: 404},
+		"get invalid id":        {method: "GET", rawURL: "/api/v1/feeds/abc", expectedStatusCode: 400},
+		"add":                   {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": "https://other.example/rss", "provider": "other", "category": "tech"}`, expectedStatusCode: 201},
+		"add duplicate":         {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": "https://provider.example/rss", "provider": "provider", "category": "category"}`, expectedStatusCode: 409},
+		"add invalid url":       {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": "not a url", "provider": "other", "category": "tech"}`, expectedStatusCode: 400},
+		"add too short polling": {method: "POST", rawURL: "/api/v1/feeds", body: `{"url": "https://other.example/rss", "provider": "other", "category": "tech", "poll_interval_seconds": 5}`, expectedStatusCode: 400},
+		"update":                {method: "PUT", rawURL: "/api/v1/feeds/1", body: `{"url": "https://provider.example/rss", "provider": "provider", "category": "category", "enabled": false}`, expectedStatusCode: 204},
+		"update not found":      {method: "PUT", rawURL: "/api/v1/feeds/9", body: `{"url": "https://provider.example/rss", "provider": "provider", "category": "category"}`, expectedStatusCode: 404},
+		"delete":                {method: "DELETE", rawURL: "/api/v1/feeds/1", expectedStatusCode: 204},
+		"delete not found":      {method: "DELETE", rawURL: "/api/v1/feeds/9", expectedStatusCode: 404},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest(test.method, test.rawURL, bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(test.expectedStatusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// logLevel is the log level of the service, as returned by the log level endpoints.
+type logLevel struct {
+	Level log.Level `json:"level"`
+	// RevertTo and RevertAt are the level the service reverts to and when, if the level was changed for a while.
+	RevertTo *log.Level `json:"revert_to,omitempty"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+}
+
+// GetLogLevel handles requests to get the log level.
+func (s *Server) GetLogLevel(c *gin.Context) {
+	c.JSON(200, s.currentLogLevel())
+}
+
+// SetLogLevel handles requests to change the log level, either for good or for a while.
+// Unless the request says otherwise, the level reverts after the configured default duration, if any.
+func (s *Server) SetLogLevel(c *gin.Context) {
+	bodyData := struct {
+		Level       string  `json:"level" binding:"required,oneof=debug info warning error"`
+		RevertAfter *string `json:"revert_after"`
+	}{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	level, err := core.ParseLogLevel(bodyData.Level)
+	if err != nil {
+		RespondWithInvalidField(c, "level", err.Error())
+		return
+	}
+
+	// A revert_after of "0" keeps the level for good
+	revertAfter := s.logLevelRevertAfter
+	if bodyData.RevertAfter != nil {
+		revertAfter, err = time.ParseDuration(*bodyData.RevertAfter)
+		if err != nil || revertAfter < 0 {
+			RespondWithInvalidField(c, "revert_after", "must be a positive duration, like 15m")
+			return
+		}
+	}
+
+	previous := s.logLevels.Level()
+	if revertAfter > 0 {
+		s.logLevels.SetLevelFor(level, revertAfter)
+	} else {
+		s.logLevels.SetLevel(level)
+	}
+
+	// Logged at the warning level so that the change is seen whatever the level
+	current := s.currentLogLevel()
+	fields := []log.FieldFunc{log.Field("previous_level", previous.String()), log.Field("level", level.String())}
+	if current.RevertAt != nil {
+		fields = append(fields, log.Field("revert_at", current.RevertAt.Format(time.RFC3339)))
+	}
+	s.logger(c).Warn("log level changed", fields...)
+
+	c.JSON(200, current)
+}
+
+// currentLogLevel returns the log level of the service.
+func (s *Server) currentLogLevel() logLevel {
+	current := logLevel{Level: s.logLevels.Level()}
+	if revertTo, revertAt, ok := s.logLevels.PendingRevert(); ok {
+		revertAt = revertAt.UTC()
+		current.RevertTo, current.RevertAt = &revertTo, &revertAt
+	}
+	return current
+}
@@ -0,0 +1,84 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogLevel(t *testing.T) {
+	const adminKey = "admin-key-0123456789"
+
+	tests := map[string]struct {
+		key                string
+		body               string
+		expectedStatusCode int
+		expectedLevel      log.Level
+		expectedRevert     bool
+	}{
+		"without key":             {body: `{"level": "debug"}`, expectedStatusCode: 401, expectedLevel: log.INFO},
+		"default revert":          {key: adminKey, body: `{"level": "debug"}`, expectedStatusCode: 200, expectedLevel: log.DEBUG, expectedRevert: true},
+		"given revert":            {key: adminKey, body: `{"level": "debug", "revert_after": "5m"}`, expectedStatusCode: 200, expectedLevel: log.DEBUG, expectedRevert: true},
+		"for good":                {key: adminKey, body: `{"level": "warning", "revert_after": "0"}`, expectedStatusCode: 200, expectedLevel: log.WARN},
+		"unknown level":           {key: adminKey, body: `{"level": "verbose"}`, expectedStatusCode: 400, expectedLevel: log.INFO},
+		"invalid revert duration": {key: adminKey, body: `{"level": "debug", "revert_after": "soon"}`, expectedStatusCode: 400, expectedLevel: log.INFO},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := core.NewAppLogger(zapcore.AddSync(io.Discard), log.INFO)
+			server := api.NewServer("", 9999, false, log.NullLogger{}, &mocks.Repository{},
+				api.WithAPIKeyAuth(adminKey), api.WithLogLevelControl(logger, time.Hour))
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("PUT", "/api/v1/admin/loglevel", bytes.NewBufferString(test.body))
+			require.NoError(t, err)
+			if test.key != "" {
+				req.Header.Set(middleware.APIKeyHeader, test.key)
+			}
+			server.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			assert.Equal(t, test.expectedLevel, logger.Level())
+			_, _, reverting := logger.PendingRevert()
+			assert.Equal(t, test.expectedRevert, reverting)
+		})
+	}
+
+	t.Run("get returns the pending revert", func(t *testing.T) {
+		logger := core.NewAppLogger(zapcore.AddSync(io.Discard), log.INFO)
+		revertAt := logger.SetLevelFor(log.DEBUG, time.Hour)
+		server := api.NewServer("", 9999, false, log.NullLogger{}, &mocks.Repository{},
+			api.WithAPIKeyAuth(adminKey), api.WithLogLevelControl(logger, 0))
+		w := httptest.NewRecorder()
+
+		req, err := http.NewRequest("GET", "/api/v1/admin/loglevel", nil)
+		require.NoError(t, err)
+		req.Header.Set(middleware.APIKeyHeader, adminKey)
+		server.Router.ServeHTTP(w, req)
+		require.Equal(t, 200, w.Code)
+
+		response := struct {
+			Level    string    `json:"level"`
+			RevertTo string    `json:"revert_to"`
+			RevertAt time.Time `json:"revert_at"`
+		}{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "debug", response.Level)
+		assert.Equal(t, "info", response.RevertTo)
+		assert.True(t, revertAt.Equal(response.RevertAt))
+	})
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+)
+
+// maxOPMLSize is the maximum size of an imported OPML document.
+const maxOPMLSize = 5 << 20
+
+// OPMLImportReport describes the changes made (or that would be made, on a dry run) by an OPML import.
+type OPMLImportReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   []entities.Feed   `json:"created"`
+	Updated   []entities.Feed   `json:"updated"`
+	Unchanged []entities.Feed   `json:"unchanged"`
+	Invalid   []OPMLInvalidFeed `json:"invalid"`
+}
+
+// OPMLInvalidFeed is a feed of an OPML document that can't be imported.
+type OPMLInvalidFeed struct {
+	URL      string `json:"url"`
+	Provider string `json:"provider"`
+	Category string `json:"category"`
+	Error    string `json:"error"`
+}
+
+// ExportFeedsOPML handles requests to export the feed sources as an OPML document.
+func (s *Server) ExportFeedsOPML(c *gin.Context) {
+	feeds, err := s.repo(c).GetFeeds()
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := feed.WriteOPML(&buf, "News App Feed Sources", feeds); err != nil {
+		s.logger(c).Error(fmt.Sprintf("error rendering OPML: %s", err.Error()))
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.Data(200, feed.OPMLContentType, buf.Bytes())
+}
+
+// ImportFeedsOPML handles requests to import feed sources from an OPML document.
+//
+// Outline titles are mapped to providers and outline categories (or the enclosing outline) to categories.
+// Feeds whose URL isn't registered yet are created, the ones already registered get their provider and
+// category updated. Registered feeds missing from the document are left alone.
+// With the 'dry_run' query parameter set, the changes are reported but not applied.
+func (s *Server) ImportFeedsOPML(c *gin.Context) {
+	queryParams := struct {
+		DryRun bool `form:"dry_run"`
+	}{}
+
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return
+	}
+
+	opmlFeeds, err := feed.ParseOPML(io.LimitReader(c.Request.Body, maxOPMLSize))
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	feeds, err := s.repo(c).GetFeeds()
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	existing := make(map[string]entities.Feed, len(feeds))
+	for _, f := range feeds {
+		existing[f.URL] = f
+	}
+
+	report := OPMLImportReport{
+		DryRun:    queryParams.DryRun,
+		Created:   []entities.Feed{},
+		Updated:   []entities.Feed{},
+		Unchanged: []entities.Feed{},
+		Invalid:   []OPMLInvalidFeed{},
+	}
+
+	for _, opmlFeed := range opmlFeeds {
+		body := FeedBody{URL: opmlFeed.URL, Provider: opmlFeed.Title, Category: opmlFeed.Category}
+		if err := body.Validate(); err != nil {
+			report.Invalid = append(report.Invalid, OPMLInvalidFeed{
+				URL: body.URL, Provider: body.Provider, Category: body.Category, Error: err.Error()})
+			continue
+		}
+
+		current, ok := existing[body.URL]
+		if ok && current.Provider == body.Provider && current.Category == body.Category {
+			report.Unchanged = append(report.Unchanged, current)
+			continue
+		}
+
+		if ok {
+			current.Provider = body.Provider
+			current.Category = body.Category
+			if !queryParams.DryRun {
+				if err := s.repo(c).UpdateFeed(current); err != nil {
+					s.logger(c).Error(err.Error())
+					RespondWithError(c, 500, CodeInternalError, "Internal error")
+					return
+				}
+			}
+
+			report.Updated = append(report.Updated, current)
+			existing[current.URL] = current
+			continue
+		}
+
+		newFeed := body.Feed(0)
+		if !queryParams.DryRun {
+			newFeed, err = s.repo(c).AddFeed(newFeed)
+			if err != nil {
+				s.logger(c).Error(err.Error())
+				RespondWithError(c, 500, CodeInternalError, "Internal error")
+				return
+			}
+		}
+
+		report.Created = append(report.Created, newFeed)
+		existing[newFeed.URL] = newFeed
+	}
+
+	c.JSON(200, report)
+}
@@ -0,0 +1,121 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const opmlDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Editor feeds</title></head>
+  <body>
+    <outline text="tech">
+      <outline type="rss" text="unchanged" xmlUrl="https://unchanged.example/rss"/>
+      <outline type="rss" text="renamed" title="renamed" xmlUrl="https://renamed.example/rss"/>
+      <outline type="rss" text="new" xmlUrl="https://new.example/rss" category="/news/world"/>
+    </outline>
+    <outline type="rss" text="no category" xmlUrl="https://nocategory.example/rss"/>
+  </body>
+</opml>`
+
+func TestImportFeedsOPMLHandler(t *testing.T) {
+	feeds := entities.Feeds{
+		{ID: 1, URL: "https://unchanged.example/rss", Provider: "unchanged", Category: "tech", Enabled: true},
+		{ID: 2, URL: "https://renamed.example/rss", Provider: "old name", Category: "tech", Enabled: false},
+	}
+
+	tests := map[string]struct {
+		dryRun bool
+	}{
+		"apply":   {dryRun: false},
+		"dry run": {dryRun: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockDB := &mocks.Repository{}
+			mockDB.On("GetFeeds").Return(feeds, nil)
+			mockDB.On("UpdateFeed", mock.Anything).Return(nil)
+			mockDB.On("AddFeed", mock.Anything).Return(func(f entities.Feed) entities.Feed { f.ID = 3; return f }, nil)
+
+			server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+
+			rawURL := "/api/v1/feeds.opml"
+			if test.dryRun {
+				rawURL += "?dry_run=true"
+			}
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", rawURL, bytes.NewBufferString(opmlDoc))
+			require.NoError(t, err)
+			server.Router.ServeHTTP(w, req)
+
+			require.Equal(t, 200, w.Code)
+
+			var report api.OPMLImportReport
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+
+			assert.Equal(t, test.dryRun, report.DryRun)
+			require.Len(t, report.Unchanged, 1)
+			assert.Equal(t, uint64(1), report.Unchanged[0].ID)
+			require.Len(t, report.Updated, 1)
+			assert.Equal(t, entities.Feed{ID: 2, URL: "https://renamed.example/rss", Provider: "renamed",
+				Category: "tech", Enabled: false}, report.Updated[0])
+			require.Len(t, report.Created, 1)
+			assert.Equal(t, "world", report.Created[0].Category)
+			assert.Equal(t, "new", report.Created[0].Provider)
+			require.Len(t, report.Invalid, 1)
+			assert.Equal(t, "https://nocategory.example/rss", report.Invalid[0].URL)
+
+			if test.dryRun {
+				mockDB.AssertNotCalled(t, "UpdateFeed", mock.Anything)
+				mockDB.AssertNotCalled(t, "AddFeed", mock.Anything)
+			} else {
+				mockDB.AssertNumberOfCalls(t, "UpdateFeed", 1)
+				mockDB.AssertNumberOfCalls(t, "AddFeed", 1)
+			}
+		})
+	}
+}
+
+func TestExportFeedsOPMLHandler(t *testing.T) {
+	feeds := entities.Feeds{
+		{ID: 1, URL: "https://a.example/rss", Provider: "a", Category: "tech"},
+		{ID: 2, URL: "https://b.example/rss", Provider: "b", Category: "news"},
+		{ID: 3, URL: "https://c.example/rss", Provider: "c", Category: "tech"},
+	}
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetFeeds").Return(feeds, nil)
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/api/v1/feeds.opml", nil)
+	require.NoError(t, err)
+	server.Router.ServeHTTP(w, req)
+
+	require.Equal(t, 200, w.Code)
+	assert.Equal(t, feed.OPMLContentType, w.Header().Get("Content-Type"))
+
+	// Exported documents can be imported back
+	opmlFeeds, err := feed.ParseOPML(w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, []feed.OPMLFeed{
+		{URL: "https://b.example/rss", Title: "b", Category: "news"},
+		{URL: "https://a.example/rss", Title: "a", Category: "tech"},
+		{URL: "https://c.example/rss", Title: "c", Category: "tech"},
+	}, opmlFeeds)
+}
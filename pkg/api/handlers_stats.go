@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/stats"
+)
+
+// GetArticleStats handles requests to get the number of articles published over time.
+func (s *Server) GetArticleStats(c *gin.Context) {
+	queryParams := struct {
+		Interval string     `form:"interval"`
+		GroupBy  string     `form:"group_by"`
+		From     *time.Time `form:"from"`
+		To       *time.Time `form:"to"`
+	}{
+		Interval: stats.IntervalDay,
+	}
+
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return
+	}
+
+	if !stats.ValidInterval(queryParams.Interval) {
+		RespondWithInvalidField(c, "interval",
+			"interval query parameter can only take one of these values: 'hour', 'day' or 'week'")
+		return
+	}
+
+	// group_by is a comma separated list of 'provider' and/or 'category'
+	byProvider, byCategory := false, false
+	if queryParams.GroupBy != "" {
+		for _, field := range strings.Split(queryParams.GroupBy, ",") {
+			switch strings.TrimSpace(field) {
+			case "provider":
+				byProvider = true
+			case "category":
+				byCategory = true
+			default:
+				RespondWithInvalidField(c, "group_by",
+					"group_by query parameter can only contain 'provider' and/or 'category'")
+				return
+			}
+		}
+	}
+
+	// Defaults to the last 7 days
+	to := time.Now().UTC()
+	if queryParams.To != nil {
+		to = queryParams.To.UTC()
+	}
+
+	from := to.AddDate(0, 0, -7)
+	if queryParams.From != nil {
+		from = queryParams.From.UTC()
+	}
+
+	if !from.Before(to) {
+		RespondWithInvalidField(c, "from", "from query parameter must be before the to query parameter")
+		return
+	}
+
+	counts, err := s.repo(c).GetArticleStats(queryParams.Interval, byProvider, byCategory, from, to)
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(200, counts)
+}
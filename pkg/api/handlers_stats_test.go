@@ -0,0 +1,78 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetArticleStatsHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := log.NullLogger{}
+	mockDB := setupMockDB()
+	server := api.NewServer("", 9999, false, logger, mockDB)
+	router := server.Router
+
+	tests := map[string]struct {
+		rawURL             string
+		expectedStatusCode int
+		expectedCounts     entities.ArticleCounts
+	}{
+		"invalid interval": {
+			rawURL:             "/api/v1/stats/articles?interval=month",
+			expectedStatusCode: 400,
+		},
+		"invalid group by": {
+			rawURL:             "/api/v1/stats/articles?group_by=link",
+			expectedStatusCode: 400,
+		},
+		"from after to": {
+			rawURL:             "/api/v1/stats/articles?from=2020-12-01T00:00:00Z&to=2020-01-01T00:00:00Z",
+			expectedStatusCode: 400,
+		},
+		"daily without grouping": {
+			rawURL:             "/api/v1/stats/articles?interval=day&from=2020-05-01T00:00:00Z&to=2020-06-01T00:00:00Z",
+			expectedStatusCode: 200,
+			expectedCounts: entities.ArticleCounts{
+				{BucketStart: time.Date(2020, 5, 10, 0, 0, 0, 0, time.UTC), Count: 1},
+			},
+		},
+		"weekly by provider": {
+			rawURL:             "/api/v1/stats/articles?interval=week&group_by=provider&from=2020-01-01T00:00:00Z&to=2021-01-01T00:00:00Z",
+			expectedStatusCode: 200,
+			expectedCounts: entities.ArticleCounts{
+				{BucketStart: time.Date(2020, 1, 6, 0, 0, 0, 0, time.UTC), Provider: "provider 3", Count: 1},
+				{BucketStart: time.Date(2020, 5, 4, 0, 0, 0, 0, time.UTC), Provider: "provider 1", Count: 1},
+				{BucketStart: time.Date(2020, 10, 5, 0, 0, 0, 0, time.UTC), Provider: "provider 2", Count: 1},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			req, err := http.NewRequest("GET", test.rawURL, nil)
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(test.expectedStatusCode, w.Code)
+
+			if test.expectedCounts != nil {
+				var counts entities.ArticleCounts
+				err = json.Unmarshal(w.Body.Bytes(), &counts)
+				require.NoError(t, err)
+				assert.Equal(test.expectedCounts, counts)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+const (
+	// sseHeartbeat is how often a comment is sent on idle streams to keep proxies from timing out.
+	sseHeartbeat = 15 * time.Second
+	// replayBatchSize is the maximum number of events read from the repository at once when replaying.
+	replayBatchSize = 100
+)
+
+// StreamArticles handles requests to stream new articles as server-sent events.
+//
+// Each article added (through the API, from feeds or imported) is sent as an 'article' event, filtered by the
+// 'provider' and 'category' query parameters. Event IDs are the IDs of the article events in the outbox, which
+// follow the order articles are added in: clients reconnecting with the Last-Event-ID header (or
+// 'last_event_id' query parameter) are first sent the articles added after the last one they received, as long
+// as its event is still in the outbox.
+func (s *Server) StreamArticles(c *gin.Context) {
+	queryParams := struct {
+		Provider    string `form:"provider"`
+		Category    string `form:"category"`
+		LastEventID string `form:"last_event_id"`
+	}{}
+
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = queryParams.LastEventID
+	}
+
+	var lastID uint64
+	if lastEventID != "" {
+		var err error
+		lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			RespondWithInvalidField(c, "last_event_id", "invalid last event id")
+			return
+		}
+	}
+
+	// Streams last for as long as clients stay connected
+	if err := clearWriteDeadline(c); err != nil {
+		s.logger(c).Warn(fmt.Sprintf("error clearing write deadline: %s", err.Error()))
+	}
+
+	// Subscribe before replaying so that nothing added in the meantime is missed: the events up to the one the
+	// subscription starts after are replayed from the repository, the ones after it are received live
+	sub := s.Stream.Subscribe(queryParams.Provider, queryParams.Category)
+	defer s.Stream.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(200)
+	c.Writer.Flush()
+
+	if lastEventID != "" && lastID < sub.After {
+		if err := s.replayEvents(c, queryParams.Provider, queryParams.Category, lastID, sub.After); err != nil {
+			s.logger(c).Error(fmt.Sprintf("error replaying articles: %s", err.Error()))
+			return
+		}
+		c.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Lagging behind or shutting down, the client resumes from the last event on reconnect
+				return
+			}
+
+			// Clients can be ahead of this instance, when reconnecting to it from another one
+			if event.ID <= lastID {
+				continue
+			}
+
+			if err := writeArticleEvent(c.Writer, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+
+		c.Writer.Flush()
+	}
+}
+
+// replayEvents writes the article events with an ID greater than afterID and up to untilID whose article is of
+// the given provider and category (empty means all).
+func (s *Server) replayEvents(c *gin.Context, provider string, category string, afterID uint64,
+	untilID uint64) error {
+
+	for afterID < untilID {
+		events, err := s.repo(c).GetEventsAfter(afterID, replayBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if event.ID > untilID {
+				return nil
+			}
+			afterID = event.ID
+
+			if provider != "" && event.Article.Provider != provider {
+				continue
+			}
+			if category != "" && event.Article.Category != category {
+				continue
+			}
+
+			if err := writeArticleEvent(c.Writer, event); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < replayBatchSize {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// writeArticleEvent writes the article of an event as a server-sent event.
+func writeArticleEvent(w io.Writer, event entities.Event) error {
+	data, err := json.Marshal(event.Article)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: article\ndata: %s\n\n", event.ID, data)
+	return err
+}
@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// readEvent reads the next event from a server-sent events stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	event := sseEvent{}
+
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && event.Event != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.Data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// outboxEvents are the events in the outbox of the mock repository returned by setupOutboxMockDB.
+var outboxEvents = entities.Events{
+	{ID: 1, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 1", Provider: "provider 1"}},
+	{ID: 2, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 2", Provider: "provider 2"}},
+	{ID: 3, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 3", Provider: "provider 1"}},
+	{ID: 4, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 4", Provider: "provider 1"}},
+}
+
+// setupOutboxMockDB returns a mock repository whose outbox holds outboxEvents.
+func setupOutboxMockDB() *mocks.Repository {
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetEventsAfter", mock.Anything, mock.Anything).
+		Return(func(afterID uint64, limit int) entities.Events {
+			events := entities.Events{}
+			for _, event := range outboxEvents {
+				if event.ID > afterID && len(events) < limit {
+					events = append(events, event)
+				}
+			}
+			return events
+		}, nil)
+	return mockDB
+}
+
+func TestStreamArticlesHandler(t *testing.T) {
+	// The server's broker is where events 1 to 3 were published when streams are opened
+	setup := func(t *testing.T) (*mocks.Repository, *api.Server, *httptest.Server) {
+		mockDB := setupOutboxMockDB()
+		server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+		server.Stream.Seek(3)
+
+		httpServer := httptest.NewServer(server.Router)
+		t.Cleanup(httpServer.Close)
+		return mockDB, server, httpServer
+	}
+
+	openStream := func(t *testing.T, ctx context.Context, rawURL string, lastEventID string) *http.Response {
+		req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+		require.NoError(t, err)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Articles reach the stream as events of the outbox, whatever they were added through
+	publish := func(t *testing.T, server *api.Server, id uint64, provider string) {
+		require.NoError(t, server.Stream.Publish(entities.Event{ID: id, Type: entities.EventArticleCreated,
+			Article: entities.Article{GUID: "guid " + strconv.FormatUint(id, 10), Provider: provider}}))
+	}
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		_, _, httpServer := setup(t)
+
+		resp := openStream(t, context.Background(), httpServer.URL+"/api/v1/articles:stream", "1:guid%201")
+		defer resp.Body.Close()
+
+		assert.Equal(t, 400, resp.StatusCode)
+	})
+
+	t.Run("live articles filtered by provider", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mockDB, server, httpServer := setup(t)
+
+		resp := openStream(t, ctx, httpServer.URL+"/api/v1/articles:stream?provider=provider%201", "")
+		defer resp.Body.Close()
+		require.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		publish(t, server, 4, "provider 2")
+		publish(t, server, 5, "provider 1")
+
+		event := readEvent(t, bufio.NewReader(resp.Body))
+		assert.Equal(t, "article", event.Event)
+		assert.Equal(t, "5", event.ID)
+
+		article := entities.Article{}
+		require.NoError(t, json.Unmarshal([]byte(event.Data), &article))
+		assert.Equal(t, "guid 5", article.GUID)
+		mockDB.AssertNotCalled(t, "GetEventsAfter", mock.Anything, mock.Anything)
+	})
+
+	t.Run("resume from last event id", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mockDB, server, httpServer := setup(t)
+
+		resp := openStream(t, ctx, httpServer.URL+"/api/v1/articles:stream?provider=provider%201", "1")
+		defer resp.Body.Close()
+		require.Equal(t, 200, resp.StatusCode)
+
+		// Events up to the last one published are replayed from the outbox, whatever their published date
+		reader := bufio.NewReader(resp.Body)
+		event := readEvent(t, reader)
+		assert.Equal(t, "3", event.ID)
+		assert.Contains(t, event.Data, `"guid":"guid 3"`)
+		mockDB.AssertCalled(t, "GetEventsAfter", uint64(1), 100)
+
+		// The ones after it are sent live, rather than replayed
+		publish(t, server, 4, "provider 1")
+		event = readEvent(t, reader)
+		assert.Equal(t, "4", event.ID)
+	})
+
+	t.Run("resume from a last event id this instance hasn't reached", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		mockDB, server, httpServer := setup(t)
+
+		resp := openStream(t, ctx, httpServer.URL+"/api/v1/articles:stream", "5")
+		defer resp.Body.Close()
+		require.Equal(t, 200, resp.StatusCode)
+
+		publish(t, server, 4, "provider 1")
+		publish(t, server, 5, "provider 1")
+		publish(t, server, 6, "provider 1")
+
+		event := readEvent(t, bufio.NewReader(resp.Body))
+		assert.Equal(t, "6", event.ID)
+		mockDB.AssertNotCalled(t, "GetEventsAfter", mock.Anything, mock.Anything)
+	})
+
+	t.Run("outlasts the write timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server := api.NewServer("", 9999, false, log.NullLogger{}, setupOutboxMockDB())
+
+		ts := httptest.NewUnstartedServer(server.HTTPServer.Handler)
+		ts.Config.WriteTimeout = 100 * time.Millisecond
+		ts.Start()
+		defer ts.Close()
+
+		resp := openStream(t, ctx, ts.URL+"/api/v1/articles:stream", "")
+		defer resp.Body.Close()
+		require.Equal(t, 200, resp.StatusCode)
+
+		time.Sleep(200 * time.Millisecond)
+		publish(t, server, 1, "provider 1")
+
+		event := readEvent(t, bufio.NewReader(resp.Body))
+		assert.Equal(t, "1", event.ID)
+	})
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/webhook"
+)
+
+// webhookLookupTimeout is the time the host of a new webhook has to resolve.
+const webhookLookupTimeout = 5 * time.Second
+
+// GetWebhooks handles requests to get all webhooks. Secrets are not returned.
+func (s *Server) GetWebhooks(c *gin.Context) {
+	webhooks, err := s.repo(c).GetWebhooks()
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	c.JSON(200, webhooks)
+}
+
+// GetWebhook handles requests to get a webhook. The secret is not returned.
+func (s *Server) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := s.repo(c).GetWebhook(id)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeWebhookNotFound, "webhook not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	webhook.Secret = ""
+	c.JSON(200, webhook)
+}
+
+// AddWebhook handles requests to add a webhook.
+// If no secret is given one is generated. The response is the only time the secret is returned.
+func (s *Server) AddWebhook(c *gin.Context) {
+	bodyData := struct {
+		URL      string `json:"url" binding:"required,url,max=500"`
+		Provider string `json:"provider" binding:"max=30"`
+		Category string `json:"category" binding:"max=30"`
+		Secret   string `json:"secret" binding:"omitempty,min=16,max=100"`
+	}{}
+
+	err := c.ShouldBindJSON(&bodyData)
+	if err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing body: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidBody, err)
+		return
+	}
+
+	// Webhooks can't be used to reach internal services
+	ctx, cancel := context.WithTimeout(c.Request.Context(), webhookLookupTimeout)
+	defer cancel()
+	if err := webhook.ValidateURL(ctx, bodyData.URL); err != nil {
+		RespondWithInvalidField(c, "url", err.Error())
+		return
+	}
+
+	if bodyData.Secret == "" {
+		bodyData.Secret, err = generateSecret()
+		if err != nil {
+			s.logger(c).Error(fmt.Sprintf("error generating secret: %s", err.Error()))
+			RespondWithError(c, 500, CodeInternalError, "Internal error")
+			return
+		}
+	}
+
+	webhook, err := s.repo(c).AddWebhook(entities.Webhook{
+		URL:       bodyData.URL,
+		Provider:  bodyData.Provider,
+		Category:  bodyData.Category,
+		Secret:    bodyData.Secret,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(201, webhook)
+}
+
+// DeleteWebhook handles requests to delete a webhook along with its delivery log.
+func (s *Server) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	err := s.repo(c).DeleteWebhook(id)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeWebhookNotFound, "webhook not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.Status(204)
+}
+
+// GetWebhookDeliveries handles requests to get the most recent deliveries of a webhook.
+func (s *Server) GetWebhookDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	queryParams := struct {
+		Limit int `form:"limit"`
+	}{
+		Limit: 50,
+	}
+
+	if err := c.ShouldBindQuery(&queryParams); err != nil {
+		s.logger(c).Info(fmt.Sprintf("error parsing query parameters: %s", err.Error()))
+		RespondWithBindingError(c, CodeInvalidQuery, err)
+		return
+	}
+
+	// Limit can have a max of 200
+	if queryParams.Limit < 1 || queryParams.Limit > 200 {
+		RespondWithInvalidField(c, "limit", "limit query parameter can be a minimum of 1 and a maximum of 200")
+		return
+	}
+
+	_, err := s.repo(c).GetWebhook(id)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		RespondWithError(c, 404, CodeWebhookNotFound, "webhook not found")
+		return
+	} else if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	deliveries, err := s.repo(c).GetWebhookDeliveries(id, queryParams.Limit)
+	if err != nil {
+		s.logger(c).Error(err.Error())
+		RespondWithError(c, 500, CodeInternalError, "Internal error")
+		return
+	}
+
+	c.JSON(200, deliveries)
+}
+
+// webhookID parses the webhook ID path parameter.
+// If it returns false, a response has already been sent.
+func webhookID(c *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		RespondWithInvalidField(c, "id", "webhook id must be a positive integer")
+		return 0, false
+	}
+
+	return id, true
+}
+
+// generateSecret returns a random hex encoded secret.
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddWebhookHandler(t *testing.T) {
+	mockDB := &mocks.Repository{}
+	mockDB.On("AddWebhook", mock.Anything).
+		Return(func(w entities.Webhook) entities.Webhook { w.ID = 1; return w }, nil)
+
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB)
+	router := server.Router
+
+	tests := map[string]struct {
+		url                string
+		expectedStatusCode int
+	}{
+		"public address":       {url: "https://93.184.216.34/hook", expectedStatusCode: 201},
+		"loopback address":     {url: "http://127.0.0.1:8080/hook", expectedStatusCode: 400},
+		"localhost":            {url: "http://localhost/hook", expectedStatusCode: 400},
+		"private address":      {url: "http://192.168.1.10/hook", expectedStatusCode: 400},
+		"metadata service":     {url: "http://169.254.169.254/latest/meta-data", expectedStatusCode: 400},
+		"unsupported protocol": {url: "ftp://93.184.216.34/hook", expectedStatusCode: 400},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/api/v1/webhooks", strings.NewReader(`{"url": "`+test.url+`"}`))
+			require.NoError(t, err)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatusCode, w.Code)
+			if test.expectedStatusCode != 400 {
+				return
+			}
+
+			var problem api.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			require.Len(t, problem.Errors, 1)
+			assert.Equal(t, "url", problem.Errors[0].Field)
+		})
+	}
+
+	mockDB.AssertNumberOfCalls(t, "AddWebhook", 1)
+}
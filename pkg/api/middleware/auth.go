@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+)
+
+// APIKeyHeader is the header holding the API key of a request.
+const APIKeyHeader = "X-API-Key"
+
+// Scopes granted to clients.
+const (
+	ScopeArticlesRead  = "articles:read"
+	ScopeArticlesWrite = "articles:write"
+	ScopeAdmin         = "admin"
+)
+
+// identityKey is the gin context key under which the identity of authenticated requests is stored.
+const identityKey = "identity"
+
+// Identity identifies the client of an authenticated request.
+type Identity struct {
+	// Method is how the client was authenticated: 'apikey', 'admin' or 'jwt'.
+	Method string
+	ID     string
+	Name   string
+	Scopes []string
+}
+
+// HasScope reports whether the client was granted a scope.
+func (i Identity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SetIdentity stores the identity of the client in the request context.
+func SetIdentity(c *gin.Context, identity Identity) {
+	c.Set(identityKey, identity)
+}
+
+// GetIdentity returns the identity of the client, if the request was authenticated.
+func GetIdentity(c *gin.Context) (Identity, bool) {
+	value, ok := c.Get(identityKey)
+	if !ok {
+		return Identity{}, false
+	}
+
+	identity, ok := value.(Identity)
+	return identity, ok
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of an API key, which is what gets stored.
+// Keys are random with enough entropy that a slow hash isn't needed.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticator authenticates requests with the credentials it's configured to accept:
+//   - The admin key, in the X-API-Key header, granting the admin scope.
+//   - API keys stored in the repository, in the X-API-Key header, granting the articles scopes.
+//   - JWTs, in the Authorization header as bearer tokens, granting the scopes they hold.
+type Authenticator struct {
+	Logger  log.Logger
+	Respond ErrorResponder
+
+	// Repo is where API keys are looked up, API keys are not accepted if nil.
+	Repo core.Repository
+	// AdminKey is not accepted if empty.
+	AdminKey string
+	// JWT validates tokens, which are not accepted if nil.
+	JWT *JWTValidator
+}
+
+// Authenticate returns a gin.HandlerFunc (middleware) that stores the identity of the client of requests with
+// credentials. Requests with invalid credentials are rejected, requests without credentials go through
+// anonymously: routes requiring authentication check it with RequireScope.
+func (a *Authenticator) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authorization := c.GetHeader("Authorization"); authorization != "" && a.JWT != nil {
+			token := strings.TrimPrefix(authorization, "Bearer ")
+			if token == authorization {
+				a.reject(c, 401, CodeInvalidCredentials, "unsupported authorization scheme")
+				return
+			}
+
+			claims, err := a.JWT.Validate(token, time.Now())
+			if err != nil {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				a.reject(c, 401, CodeInvalidCredentials, err.Error())
+				return
+			}
+
+			SetIdentity(c, Identity{Method: "jwt", Name: claims.Subject, Scopes: claims.AllScopes()})
+		} else if key := c.GetHeader(APIKeyHeader); key != "" {
+			if !a.authenticateKey(c, key) {
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// authenticateKey authenticates a request with the admin key or an API key.
+// If it returns false, a response has already been sent.
+func (a *Authenticator) authenticateKey(c *gin.Context, key string) bool {
+	keyHash := HashAPIKey(key)
+
+	// Comparing hashes keeps the comparison constant time regardless of the key length
+	if a.AdminKey != "" && subtle.ConstantTimeCompare([]byte(keyHash), []byte(HashAPIKey(a.AdminKey))) == 1 {
+		SetIdentity(c, Identity{Method: "admin", Name: "admin", Scopes: []string{ScopeAdmin}})
+		return true
+	}
+
+	if a.Repo == nil {
+		a.reject(c, 401, CodeInvalidCredentials, "invalid API key")
+		return false
+	}
+
+	apiKey, err := core.RepositoryWithContext(c.Request.Context(), a.Repo).GetAPIKeyByHash(keyHash)
+	if _, ok := err.(*repository.DBNotFoundError); ok {
+		a.reject(c, 401, CodeInvalidCredentials, "invalid API key")
+		return false
+	} else if err != nil {
+		RequestLogger(c, a.Logger).Error(fmt.Sprintf("error looking up API key: %s", err.Error()))
+		a.reject(c, 500, CodeInternalError, "Internal error")
+		return false
+	}
+
+	if !apiKey.Enabled {
+		a.reject(c, 401, CodeAPIKeyRevoked, "API key revoked")
+		return false
+	}
+
+	SetIdentity(c, Identity{
+		Method: "apikey",
+		ID:     strconv.FormatUint(apiKey.ID, 10),
+		Name:   apiKey.Label,
+		Scopes: []string{ScopeArticlesRead, ScopeArticlesWrite},
+	})
+	return true
+}
+
+// RequireScope returns a gin.HandlerFunc (middleware) that only lets through requests authenticated with a
+// given scope. It must run after Authenticate.
+func (a *Authenticator) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity, ok := GetIdentity(c)
+		if !ok {
+			if a.JWT != nil {
+				c.Header("WWW-Authenticate", "Bearer")
+			}
+			a.reject(c, 401, CodeMissingCredentials, "missing credentials")
+			return
+		}
+
+		if !identity.HasScope(scope) {
+			if identity.Method == "jwt" {
+				c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+			}
+			a.reject(c, 403, CodeInsufficientScope, fmt.Sprintf("missing scope: %s", scope))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// reject sends an error response and stops the handler chain.
+func (a *Authenticator) reject(c *gin.Context, httpCode int, code string, message string) {
+	a.Respond(c, httpCode, code, message)
+	c.Abort()
+}
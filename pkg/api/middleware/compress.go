@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Content codings supported, in order of preference.
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// Compress returns a gin.HandlerFunc (middleware) that compresses responses with brotli or gzip, according to
+// the Accept-Encoding header of requests. Responses smaller than minSize bytes are sent as they are, as are
+// the ones already encoded by their handler and server-sent events streams.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := NegotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = writer
+		defer writer.close()
+
+		c.Next()
+	}
+}
+
+// Decompress returns a gin.HandlerFunc (middleware) that decompresses request bodies encoded with brotli or
+// gzip, up to maxSize bytes. Requests with a body in any other content coding are rejected with a 415.
+func Decompress(maxSize int64, respond ErrorResponder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body io.Reader
+		switch encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding"))); encoding {
+		case "", "identity":
+			c.Next()
+			return
+		case EncodingBrotli:
+			body = brotli.NewReader(c.Request.Body)
+		case EncodingGzip:
+			gz, err := gzip.NewReader(c.Request.Body)
+			if err != nil {
+				respond(c, 400, CodeInvalidBody, fmt.Sprintf("invalid gzip body: %s", err.Error()))
+				c.Abort()
+				return
+			}
+			body = gz
+		default:
+			c.Header("Accept-Encoding", EncodingBrotli+", "+EncodingGzip)
+			respond(c, 415, CodeUnsupportedEncoding, fmt.Sprintf("unsupported content encoding: %s", encoding))
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, readCloser{Reader: body, Closer: c.Request.Body}, maxSize)
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+		// GetBody would return the compressed body
+		c.Request.GetBody = nil
+
+		c.Next()
+	}
+}
+
+// readCloser reads from a decoder and closes the underlying body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// NegotiateEncoding returns the preferred content coding accepted by a client (EncodingBrotli or EncodingGzip),
+// given its Accept-Encoding header, or an empty string if none is.
+func NegotiateEncoding(acceptEncoding string) string {
+	best, bestQuality := "", 0.0
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(item, ";")
+		encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if encoding != EncodingBrotli && encoding != EncodingGzip {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			if value := strings.TrimSpace(param); strings.HasPrefix(value, "q=") {
+				if q, err := strconv.ParseFloat(value[2:], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+
+		// Brotli wins ties, as it's listed first by preference
+		if quality > bestQuality || (quality == bestQuality && encoding == EncodingBrotli) {
+			best, bestQuality = encoding, quality
+		}
+	}
+
+	return best
+}
+
+// Encoder compresses what's written to it in a content coding.
+type Encoder interface {
+	io.WriteCloser
+	// Flush writes the data compressed so far.
+	Flush() error
+}
+
+// NewEncoder returns an Encoder writing to w in the given content coding (EncodingBrotli or EncodingGzip).
+func NewEncoder(w io.Writer, encoding string) Encoder {
+	if encoding == EncodingBrotli {
+		return brotli.NewWriterLevel(w, brotli.DefaultCompression)
+	}
+	return gzip.NewWriter(w)
+}
+
+// compressWriter buffers the beginning of responses until they reach the minimum size to be compressed, and
+// compresses them from there on.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	// checked is set once the headers were checked on the first write, decided once the response is known to
+	// be compressed (encoder is set) or not.
+	checked bool
+	decided bool
+	buf     []byte
+	encoder Encoder
+}
+
+// Write implements io.Writer.
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.check()
+
+	switch {
+	case w.encoder != nil:
+		return w.encoder.Write(data)
+	case w.decided:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startEncoding(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString implements gin.ResponseWriter.
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush implements http.Flusher. A response flushed before reaching the minimum size is compressed anyway,
+// as more is likely to come.
+func (w *compressWriter) Flush() {
+	w.check()
+	if !w.decided {
+		_ = w.startEncoding()
+	}
+
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// check decides whether the response is compressed on its first write or flush, once its headers are set.
+func (w *compressWriter) check() {
+	if !w.checked {
+		w.checked = true
+		w.decided = !w.compressible()
+	}
+}
+
+// compressible reports whether the response can be compressed.
+func (w *compressWriter) compressible() bool {
+	status := w.ResponseWriter.Status()
+	if status < 200 || status == 204 || status == 304 {
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		return false
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+	return true
+}
+
+// startEncoding sets the headers of compressed responses and compresses the buffered data.
+func (w *compressWriter) startEncoding() error {
+	w.decided = true
+
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	// The encoded representation isn't byte for byte the one the ETag was computed for
+	if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	w.encoder = NewEncoder(w.ResponseWriter, w.encoding)
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.encoder.Write(buf)
+	return err
+}
+
+// close sends what's left of the response: the buffered data if it wasn't compressed, the end of the
+// compressed stream otherwise.
+func (w *compressWriter) close() {
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		return
+	}
+
+	if len(w.buf) > 0 {
+		_, _ = w.ResponseWriter.Write(w.buf)
+	}
+}
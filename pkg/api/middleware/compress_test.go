@@ -0,0 +1,154 @@
+package middleware_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func respondWithCode(c *gin.Context, httpCode int, code string, message string, _ ...middleware.FieldError) {
+	c.JSON(httpCode, gin.H{"code": code, "message": message})
+}
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat("article ", 200)
+
+	router := gin.New()
+	router.Use(middleware.Compress(1024))
+	router.GET("/large", func(c *gin.Context) {
+		c.Header("ETag", `"abc"`)
+		c.String(200, large)
+	})
+	router.GET("/small", func(c *gin.Context) { c.String(200, "article") })
+	router.GET("/encoded", func(c *gin.Context) {
+		c.Header("Content-Encoding", "gzip")
+		c.Data(200, "application/octet-stream", []byte("already encoded"))
+	})
+	router.GET("/events", func(c *gin.Context) { c.Data(200, "text/event-stream", []byte(large)) })
+	router.GET("/empty", func(c *gin.Context) { c.Status(304) })
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	}
+
+	tests := map[string]struct {
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		"brotli":                {path: "/large", acceptEncoding: "gzip, deflate, br", expectedEncoding: "br"},
+		"gzip":                  {path: "/large", acceptEncoding: "gzip", expectedEncoding: "gzip"},
+		"gzip preferred":        {path: "/large", acceptEncoding: "br;q=0.5, gzip", expectedEncoding: "gzip"},
+		"brotli refused":        {path: "/large", acceptEncoding: "br;q=0, gzip;q=0.1", expectedEncoding: "gzip"},
+		"not accepted":          {path: "/large", acceptEncoding: "deflate"},
+		"no accept encoding":    {path: "/large"},
+		"below threshold":       {path: "/small", acceptEncoding: "br"},
+		"already encoded":       {path: "/encoded", acceptEncoding: "br", expectedEncoding: "gzip"},
+		"server-sent events":    {path: "/events", acceptEncoding: "br"},
+		"response without body": {path: "/empty", acceptEncoding: "br"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", test.path, nil)
+			require.NoError(t, err)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedEncoding, w.Header().Get("Content-Encoding"))
+			decode, ok := decoders[test.expectedEncoding]
+			if !ok || test.path != "/large" {
+				return
+			}
+
+			assert.Equal(t, []string{"Accept-Encoding"}, w.Header().Values("Vary"))
+			assert.Equal(t, `W/"abc"`, w.Header().Get("ETag"))
+			assert.Less(t, w.Body.Len(), len(large))
+
+			reader, err := decode(w.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, large, string(body))
+		})
+	}
+
+	// The small response is sent as it is
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/small", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "br")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "article", w.Body.String())
+}
+
+func TestDecompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/articles", middleware.Decompress(1024, respondWithCode), func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.String(400, err.Error())
+			return
+		}
+		c.String(200, string(body))
+	})
+
+	var gzipped, brotlied bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write([]byte(`[{"guid": "guid 1"}]`))
+	require.NoError(t, gz.Close())
+	br := brotli.NewWriter(&brotlied)
+	_, _ = br.Write([]byte(`[{"guid": "guid 1"}]`))
+	require.NoError(t, br.Close())
+
+	var bomb bytes.Buffer
+	gz = gzip.NewWriter(&bomb)
+	_, _ = gz.Write(bytes.Repeat([]byte(" "), 2048))
+	require.NoError(t, gz.Close())
+
+	tests := map[string]struct {
+		contentEncoding string
+		body            []byte
+		expectedStatus  int
+		expectedBody    string
+	}{
+		"plain":        {body: []byte(`[{"guid": "guid 1"}]`), expectedStatus: 200, expectedBody: `[{"guid": "guid 1"}]`},
+		"gzip":         {contentEncoding: "gzip", body: gzipped.Bytes(), expectedStatus: 200, expectedBody: `[{"guid": "guid 1"}]`},
+		"brotli":       {contentEncoding: "br", body: brotlied.Bytes(), expectedStatus: 200, expectedBody: `[{"guid": "guid 1"}]`},
+		"invalid gzip": {contentEncoding: "gzip", body: []byte("not gzip"), expectedStatus: 400, expectedBody: middleware.CodeInvalidBody},
+		"too large":    {contentEncoding: "gzip", body: bomb.Bytes(), expectedStatus: 400, expectedBody: "too large"},
+		"unsupported":  {contentEncoding: "deflate", body: []byte("deflated"), expectedStatus: 415, expectedBody: middleware.CodeUnsupportedEncoding},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("POST", "/articles", bytes.NewReader(test.body))
+			require.NoError(t, err)
+			if test.contentEncoding != "" {
+				req.Header.Set("Content-Encoding", test.contentEncoding)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), test.expectedBody)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exposedHeaders are the response headers of the API that browsers let cross-origin clients read, on top of
+// the CORS-safelisted ones.
+var exposedHeaders = []string{RequestIDHeader, "ETag", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After"}
+
+// CORSOptions configures which cross-origin requests browsers are allowed to make.
+type CORSOptions struct {
+	// AllowedOrigins are the origins (e.g. 'https://app.example.com') allowed, '*' allowing any.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is the number of seconds browsers can cache preflight responses for (0 leaves it to them).
+	MaxAge int
+}
+
+// CORS returns a gin.HandlerFunc (middleware) that implements cross-origin resource sharing: requests from
+// allowed origins get the Access-Control-* headers letting browsers hand their responses to clients.
+// Preflight requests are answered with a 204, or rejected with a 403 if their origin isn't allowed.
+func CORS(options CORSOptions, respond ErrorResponder) gin.HandlerFunc {
+	allowedOrigins := make(map[string]bool, len(options.AllowedOrigins))
+	for _, origin := range options.AllowedOrigins {
+		allowedOrigins[origin] = true
+	}
+	allowedMethods := strings.Join(options.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(options.AllowedHeaders, ", ")
+	exposed := strings.Join(exposedHeaders, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == "OPTIONS" && c.GetHeader("Access-Control-Request-Method") != ""
+
+		// Responses depend on the origin, unless any is allowed
+		if !allowedOrigins["*"] {
+			c.Writer.Header().Add("Vary", "Origin")
+		}
+
+		if !allowedOrigins["*"] && !allowedOrigins[origin] {
+			if preflight {
+				respond(c, 403, CodeOriginNotAllowed, "origin not allowed")
+				c.Abort()
+				return
+			}
+
+			// Browsers won't hand the response to the client without the CORS headers
+			c.Next()
+			return
+		}
+
+		if allowedOrigins["*"] {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+
+		if !preflight {
+			c.Header("Access-Control-Expose-Headers", exposed)
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Methods", allowedMethods)
+		c.Header("Access-Control-Allow-Headers", allowedHeaders)
+		if options.MaxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(options.MaxAge))
+		}
+		c.AbortWithStatus(204)
+	}
+}
@@ -0,0 +1,88 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(origins ...string) *gin.Engine {
+		router := gin.New()
+		router.Use(middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins: origins,
+			AllowedMethods: []string{"GET", "POST"},
+			AllowedHeaders: []string{"Authorization", "Content-Type"},
+			MaxAge:         600,
+		}, respondWithCode))
+		router.GET("/articles", func(c *gin.Context) { c.String(200, "articles") })
+		return router
+	}
+
+	tests := map[string]struct {
+		allowedOrigins      []string
+		method              string
+		origin              string
+		preflight           bool
+		expectedStatus      int
+		expectedAllowOrigin string
+		expectedVary        []string
+	}{
+		"same origin": {allowedOrigins: []string{"https://app.example.com"}, method: "GET",
+			expectedStatus: 200},
+		"allowed origin": {allowedOrigins: []string{"https://app.example.com"}, method: "GET",
+			origin: "https://app.example.com", expectedStatus: 200,
+			expectedAllowOrigin: "https://app.example.com", expectedVary: []string{"Origin"}},
+		"other origin": {allowedOrigins: []string{"https://app.example.com"}, method: "GET",
+			origin: "https://evil.example.com", expectedStatus: 200, expectedVary: []string{"Origin"}},
+		"any origin": {allowedOrigins: []string{"*"}, method: "GET", origin: "https://evil.example.com",
+			expectedStatus: 200, expectedAllowOrigin: "*"},
+		"preflight": {allowedOrigins: []string{"https://app.example.com"}, method: "OPTIONS",
+			origin: "https://app.example.com", preflight: true, expectedStatus: 204,
+			expectedAllowOrigin: "https://app.example.com", expectedVary: []string{"Origin"}},
+		"preflight from other origin": {allowedOrigins: []string{"https://app.example.com"}, method: "OPTIONS",
+			origin: "https://evil.example.com", preflight: true, expectedStatus: 403,
+			expectedVary: []string{"Origin"}},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(test.method, "/articles", nil)
+			require.NoError(t, err)
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			if test.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+				req.Header.Set("Access-Control-Request-Headers", "Authorization")
+			}
+			newRouter(test.allowedOrigins...).ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code)
+			assert.Equal(t, test.expectedAllowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, test.expectedVary, w.Header().Values("Vary"))
+
+			switch {
+			case test.expectedAllowOrigin == "":
+				assert.Empty(t, w.Header().Get("Access-Control-Expose-Headers"))
+				assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+			case test.preflight:
+				assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+				assert.Equal(t, "Authorization, Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+				assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+				assert.Empty(t, w.Body.String())
+			default:
+				assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), middleware.RequestIDHeader)
+				assert.Equal(t, "articles", w.Body.String())
+			}
+		})
+	}
+}
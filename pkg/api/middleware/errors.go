@@ -0,0 +1,28 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// Error codes of the responses sent by the middleware.
+const (
+	CodeMissingCredentials  = "missing_credentials"
+	CodeInvalidCredentials  = "invalid_credentials"
+	CodeAPIKeyRevoked       = "api_key_revoked"
+	CodeInsufficientScope   = "insufficient_scope"
+	CodeRateLimited         = "rate_limited"
+	CodeInvalidBody         = "invalid_body"
+	CodeUnsupportedEncoding = "unsupported_encoding"
+	CodeOriginNotAllowed    = "origin_not_allowed"
+	CodeValidationFailed    = "validation_failed"
+	CodeInternalError       = "internal_error"
+)
+
+// FieldError describes why a field of a request (a parameter or a property of the body) is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponder sends an error response, so that middleware errors look like handler errors.
+// code is a stable, machine-readable identifier of the kind of error and message a description for humans.
+type ErrorResponder func(c *gin.Context, httpCode int, code string, message string, fieldErrors ...FieldError)
@@ -0,0 +1,270 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// jwtLeeway is the clock skew tolerated when checking the time claims of a token.
+const jwtLeeway = time.Minute
+
+// JWTClaims holds the claims of a token used by the service.
+type JWTClaims struct {
+	Subject   string      `json:"sub"`
+	Issuer    string      `json:"iss"`
+	Audience  jwtAudience `json:"aud"`
+	ExpiresAt *int64      `json:"exp"`
+	NotBefore *int64      `json:"nbf"`
+	// Scope holds space separated scopes (RFC 8693), Scopes a list of them. Both are accepted.
+	Scope  string   `json:"scope"`
+	Scopes []string `json:"scp"`
+}
+
+// AllScopes returns the scopes of both the 'scope' and 'scp' claims.
+func (c JWTClaims) AllScopes() []string {
+	return append(strings.Fields(c.Scope), c.Scopes...)
+}
+
+// jwtAudience is the 'aud' claim, which can either be a string or a list of strings.
+type jwtAudience []string
+
+func (a *jwtAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = jwtAudience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return errors.New("invalid audience")
+	}
+	*a = list
+	return nil
+}
+
+// JWTValidator validates HS256 and RS256 signed JWTs.
+//
+// Each algorithm is only verified with its own kind of key, so that a token can't be signed with the HMAC of
+// a public key.
+type JWTValidator struct {
+	Issuer   string
+	Audience string
+
+	hmacSecrets map[string][]byte
+	rsaKeys     map[string]*rsa.PublicKey
+}
+
+// NewJWTValidator returns a new JWTValidator for tokens of the given issuer and audience.
+// Keys are added with AddHMACSecret, AddRSAPublicKeyFile and AddJWKSFile.
+func NewJWTValidator(issuer string, audience string) *JWTValidator {
+	return &JWTValidator{
+		Issuer:      issuer,
+		Audience:    audience,
+		hmacSecrets: make(map[string][]byte),
+		rsaKeys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+// AddHMACSecret adds a secret to verify HS256 tokens with the given key ID (empty to match any).
+func (v *JWTValidator) AddHMACSecret(kid string, secret []byte) {
+	v.hmacSecrets[kid] = secret
+}
+
+// AddRSAPublicKeyFile adds a PEM encoded RSA public key to verify RS256 tokens with the given key ID (empty
+// to match any).
+func (v *JWTValidator) AddRSAPublicKeyFile(kid string, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("no PEM data in %s", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return err
+	}
+
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("not an RSA public key in %s", path)
+	}
+
+	v.rsaKeys[kid] = rsaKey
+	return nil
+}
+
+// AddJWKSFile adds the RSA ('RSA') and HMAC ('oct') keys of a JSON Web Key Set file.
+func (v *JWTValidator) AddJWKSFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	jwks := struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}{}
+
+	if err := json.Unmarshal(data, &jwks); err != nil {
+		return fmt.Errorf("invalid JWKS in %s: %s", path, err)
+	}
+
+	for _, jwk := range jwks.Keys {
+		switch jwk.Kty {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			if err != nil {
+				return fmt.Errorf("invalid modulus of key %q in %s", jwk.Kid, path)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			if err != nil || len(e) > 4 {
+				return fmt.Errorf("invalid exponent of key %q in %s", jwk.Kid, path)
+			}
+
+			v.rsaKeys[jwk.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(jwk.K)
+			if err != nil {
+				return fmt.Errorf("invalid secret of key %q in %s", jwk.Kid, path)
+			}
+			v.hmacSecrets[jwk.Kid] = secret
+		}
+	}
+
+	return nil
+}
+
+// HasKeys reports whether any key has been added.
+func (v *JWTValidator) HasKeys() bool {
+	return len(v.hmacSecrets) > 0 || len(v.rsaKeys) > 0
+}
+
+// Validate verifies the signature of a token and checks its issuer, audience, expiry and not before time.
+func (v *JWTValidator) Validate(token string, now time.Time) (JWTClaims, error) {
+	claims := JWTClaims{}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("malformed token")
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return claims, errors.New("malformed token header")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("malformed token signature")
+	}
+
+	if !v.verify(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return claims, errors.New("invalid token signature")
+	}
+
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return claims, errors.New("malformed token claims")
+	}
+
+	if claims.ExpiresAt == nil {
+		return claims, errors.New("token without expiry")
+	}
+	if now.After(time.Unix(*claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return claims, errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(time.Unix(*claims.NotBefore, 0)) {
+		return claims, errors.New("token not valid yet")
+	}
+
+	if claims.Issuer != v.Issuer {
+		return claims, errors.New("invalid token issuer")
+	}
+
+	validAudience := false
+	for _, audience := range claims.Audience {
+		validAudience = validAudience || audience == v.Audience
+	}
+	if !validAudience {
+		return claims, errors.New("invalid token audience")
+	}
+
+	return claims, nil
+}
+
+// verify checks the signature of the signed part of a token with the keys of the algorithm.
+// A key ID selects a single key, otherwise every key of the algorithm is tried.
+func (v *JWTValidator) verify(alg string, kid string, signed string, signature []byte) bool {
+	switch alg {
+	case "HS256":
+		for keyID, secret := range v.hmacSecrets {
+			if kid != "" && keyID != "" && keyID != kid {
+				continue
+			}
+
+			mac := hmac.New(sha256.New, secret)
+			mac.Write([]byte(signed))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		}
+	case "RS256":
+		digest := sha256.Sum256([]byte(signed))
+		for keyID, key := range v.rsaKeys {
+			if kid != "" && keyID != "" && keyID != kid {
+				continue
+			}
+
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// decodeJWTSegment decodes a base64url encoded JSON segment of a token.
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,140 @@
+package middleware_test
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signToken returns a JWT with the given header and claims, signed with an HMAC secret or an RSA private key.
+func signToken(t *testing.T, header map[string]interface{}, claims map[string]interface{}, key interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(header) + "." + encode(claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWTValidator(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+
+	// RSA key as a JWKS file
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "key-1",
+		"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	jwksPath := filepath.Join(dir, "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, jwks, 0600))
+
+	// Same RSA key as a PEM file
+	publicKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+	pemPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(pemPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}), 0600))
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub":   "client",
+			"iss":   "https://gateway.example",
+			"aud":   []string{"articles-mgmt"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": "articles:read articles:write",
+		}
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	rs256 := map[string]interface{}{"alg": "RS256", "typ": "JWT", "kid": "key-1"}
+
+	tests := map[string]struct {
+		token         string
+		rsaFromPEM    bool
+		expectedError string
+	}{
+		"hs256":            {token: signToken(t, hs256, validClaims(), secret)},
+		"rs256 from jwks":  {token: signToken(t, rs256, validClaims(), rsaKey)},
+		"rs256 from pem":   {token: signToken(t, rs256, validClaims(), rsaKey), rsaFromPEM: true},
+		"audience string":  {token: signToken(t, hs256, with("aud", "articles-mgmt"), secret)},
+		"malformed":        {token: "abc.def", expectedError: "malformed token"},
+		"wrong secret":     {token: signToken(t, hs256, validClaims(), []byte("other")), expectedError: "invalid token signature"},
+		"none algorithm":   {token: signToken(t, map[string]interface{}{"alg": "none"}, validClaims(), []byte{}), expectedError: "invalid token signature"},
+		"hs256 public key": {token: signToken(t, hs256, validClaims(), publicKey), expectedError: "invalid token signature"},
+		"expired":          {token: signToken(t, hs256, with("exp", now.Add(-2*time.Minute).Unix()), secret), expectedError: "token expired"},
+		"within leeway":    {token: signToken(t, hs256, with("exp", now.Add(-30*time.Second).Unix()), secret)},
+		"no expiry":        {token: signToken(t, hs256, with("exp", nil), secret), expectedError: "token without expiry"},
+		"not valid yet":    {token: signToken(t, hs256, with("nbf", now.Add(time.Hour).Unix()), secret), expectedError: "token not valid yet"},
+		"wrong issuer":     {token: signToken(t, hs256, with("iss", "https://other.example"), secret), expectedError: "invalid token issuer"},
+		"wrong audience":   {token: signToken(t, hs256, with("aud", "other"), secret), expectedError: "invalid token audience"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			validator := middleware.NewJWTValidator("https://gateway.example", "articles-mgmt")
+			validator.AddHMACSecret("", secret)
+			if test.rsaFromPEM {
+				require.NoError(t, validator.AddRSAPublicKeyFile("", pemPath))
+			} else {
+				require.NoError(t, validator.AddJWKSFile(jwksPath))
+			}
+
+			claims, err := validator.Validate(test.token, now)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "client", claims.Subject)
+			assert.Equal(t, []string{"articles:read", "articles:write"}, claims.AllScopes())
+		})
+	}
+}
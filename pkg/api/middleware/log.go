@@ -21,19 +21,18 @@ import (
 // Note: This code was copied from https://github.com/gin-contrib/zap with some modifications.
 func GinReqLogger(logger log.Logger, timeFormat string, msg string, msgType string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		start := time.Now()
+		c.Set(startKey, time.Now())
 		// some evil middlewares modify these values
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
 		c.Next()
 
-		end := time.Now()
-		latency := end.Sub(start)
+		latency := GetLatency(c)
 
 		if len(c.Errors) > 0 {
 			// Append error field if this is an erroneous request.
 			for _, e := range c.Errors.Errors() {
-				logger.Error(e, nil)
+				RequestLogger(c, logger).Error(e)
 			}
 		} else {
 			fields := log.FieldsMap{
@@ -46,13 +45,31 @@ func GinReqLogger(logger log.Logger, timeFormat string, msg string, msgType stri
 				"latency":    latency.Seconds(),
 			}
 
-			// Check if X-Request-ID header exists, if yes, log it too
-			requestID := c.Request.Header.Get("X-Request-ID")
+			// Set by the RequestID middleware, if in use
+			requestID := GetRequestID(c)
 
 			if requestID != "" {
 				fields["requestid"] = requestID
 			}
 
+			if traceID := GetTraceID(c); traceID != "" {
+				fields["traceid"] = traceID
+			}
+
+			if identity, ok := GetIdentity(c); ok {
+				fields["auth"] = identity.Method
+				if identity.ID != "" {
+					fields["client-id"] = identity.ID
+				}
+				fields["client-name"] = identity.Name
+			}
+
+			if decision, ok := GetRateLimitDecision(c); ok {
+				fields["ratelimit-key"] = decision.Key
+				fields["ratelimit-allowed"] = decision.Allowed
+				fields["ratelimit-remaining"] = decision.Remaining
+			}
+
 			if msgType != "" {
 				fields["type"] = msgType
 			}
@@ -61,3 +78,25 @@ func GinReqLogger(logger log.Logger, timeFormat string, msg string, msgType stri
 		}
 	}
 }
+
+// startKey and latencyKey are the gin context keys under which the start time and latency of a request are stored.
+const (
+	startKey   = "start"
+	latencyKey = "latency"
+)
+
+// GetLatency returns the latency of a request timed by GinReqLogger: the time from its start to the first call,
+// so that every middleware reporting it (e.g. Metrics) reports the same value. It's 0 if the request isn't timed.
+func GetLatency(c *gin.Context) time.Duration {
+	if latency, ok := c.Get(latencyKey); ok {
+		return latency.(time.Duration)
+	}
+
+	start, ok := c.Get(startKey)
+	if !ok {
+		return 0
+	}
+	latency := time.Since(start.(time.Time))
+	c.Set(latencyKey, latency)
+	return latency
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+)
+
+// routeKey is the gin context key under which the route of a request is stored, when it isn't the gin one.
+const routeKey = "route"
+
+// SetRoute sets the route a request is recorded under, for handlers that dispatch requests to several routes.
+func SetRoute(c *gin.Context, route string) {
+	c.Set(routeKey, route)
+}
+
+// GetRoute returns the route of a request: the one set with SetRoute, the gin route pattern otherwise, or
+// 'unmatched' for requests that didn't match any route.
+func GetRoute(c *gin.Context) string {
+	if route := c.GetString(routeKey); route != "" {
+		return route
+	}
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
+
+// Metrics returns a gin.HandlerFunc (middleware) that records the number and latency of requests per method,
+// route and status. The latency is the one GinReqLogger logs (see GetLatency), so it must be used after it.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		m.ObserveRequest(c.Request.Method, GetRoute(c), c.Writer.Status(), GetLatency(c))
+	}
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ParseProxies parses the addresses and CIDR ranges (e.g. '10.0.0.1' or '10.0.0.0/8') of trusted proxies.
+func ParseProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if ip := net.ParseIP(proxy); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy address or range <%s>", proxy)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// TrustedProxies returns a gin.HandlerFunc (middleware) that has gin.Context.ClientIP return the address of the
+// client a request was forwarded for by the given proxies, and the address of the connection otherwise.
+// As any client can set the X-Forwarded-For and X-Real-Ip headers, they're dropped from the requests that don't
+// come from a proxy, and the client is the last address in X-Forwarded-For that isn't a proxy's.
+// It must run ahead of the middlewares reading ClientIP.
+func TrustedProxies(proxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Request.Header
+		forwardedFor := header.Values("X-Forwarded-For")
+		realIP := header.Get("X-Real-Ip")
+		header.Del("X-Forwarded-For")
+		header.Del("X-Real-Ip")
+
+		if remote := net.ParseIP(remoteIP(c.Request)); remote == nil || !isProxy(remote, proxies) {
+			c.Next()
+			return
+		}
+
+		if client := forwardedClient(forwardedFor, proxies); client != "" {
+			header.Set("X-Forwarded-For", client)
+		} else if len(forwardedFor) == 0 && net.ParseIP(realIP) != nil {
+			header.Set("X-Real-Ip", realIP)
+		}
+
+		c.Next()
+	}
+}
+
+// forwardedClient returns the address of the client in the X-Forwarded-For headers of a request: the last one
+// that isn't a proxy's, or the first one if they all are. It returns an empty string if any address it goes
+// through is invalid.
+func forwardedClient(forwardedFor []string, proxies []*net.IPNet) string {
+	var addresses []string
+	for _, value := range forwardedFor {
+		for _, address := range strings.Split(value, ",") {
+			addresses = append(addresses, strings.TrimSpace(address))
+		}
+	}
+
+	for i := len(addresses) - 1; i >= 0; i-- {
+		ip := net.ParseIP(addresses[i])
+		if ip == nil {
+			return ""
+		}
+		if i == 0 || !isProxy(ip, proxies) {
+			return ip.String()
+		}
+	}
+
+	return ""
+}
+
+// isProxy reports whether ip is the address of one of the proxies.
+func isProxy(ip net.IP, proxies []*net.IPNet) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteIP returns the IP address of the peer a request came from.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,80 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	proxies, err := middleware.ParseProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		remoteAddr       string
+		forwardedFor     []string
+		realIP           string
+		expectedClientIP string
+	}{
+		"direct": {remoteAddr: "1.2.3.4:1234", expectedClientIP: "1.2.3.4"},
+		"direct with forwarded for": {remoteAddr: "1.2.3.4:1234", forwardedFor: []string{"5.6.7.8"},
+			expectedClientIP: "1.2.3.4"},
+		"direct with real ip": {remoteAddr: "1.2.3.4:1234", realIP: "5.6.7.8", expectedClientIP: "1.2.3.4"},
+		"through a proxy": {remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"1.2.3.4"},
+			expectedClientIP: "1.2.3.4"},
+		"through proxies": {remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"1.2.3.4, 192.168.1.1"},
+			expectedClientIP: "1.2.3.4"},
+		"through proxies in several headers": {remoteAddr: "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4", "192.168.1.1"}, expectedClientIP: "1.2.3.4"},
+		"spoofed forwarded for": {remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"5.6.7.8, 1.2.3.4"},
+			expectedClientIP: "1.2.3.4"},
+		"only proxies": {remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"192.168.1.2, 192.168.1.1"},
+			expectedClientIP: "192.168.1.2"},
+		"invalid forwarded for": {remoteAddr: "10.0.0.1:1234", forwardedFor: []string{"1.2.3.4, unknown"},
+			expectedClientIP: "10.0.0.1"},
+		"real ip through a proxy": {remoteAddr: "10.0.0.1:1234", realIP: "1.2.3.4",
+			expectedClientIP: "1.2.3.4"},
+		"proxy without forwarded for": {remoteAddr: "10.0.0.1:1234", expectedClientIP: "10.0.0.1"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var clientIP string
+			router := gin.New()
+			router.Use(middleware.TrustedProxies(proxies))
+			router.GET("/", func(c *gin.Context) { clientIP = c.ClientIP() })
+
+			req, err := http.NewRequest("GET", "/", nil)
+			require.NoError(t, err)
+			req.RemoteAddr = test.remoteAddr
+			for _, forwardedFor := range test.forwardedFor {
+				req.Header.Add("X-Forwarded-For", forwardedFor)
+			}
+			if test.realIP != "" {
+				req.Header.Set("X-Real-Ip", test.realIP)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedClientIP, clientIP)
+		})
+	}
+}
+
+func TestParseProxies(t *testing.T) {
+	proxies, err := middleware.ParseProxies([]string{"10.0.0.1", "10.1.0.0/16", "::1"})
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.Equal(t, "10.0.0.1/32", proxies[0].String())
+	assert.Equal(t, "10.1.0.0/16", proxies[1].String())
+	assert.Equal(t, "::1/128", proxies[2].String())
+
+	_, err = middleware.ParseProxies([]string{"10.0.0.1", "proxy.internal"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitKey is the gin context key under which the rate limiter decision of a request is stored.
+const rateLimitKey = "ratelimit"
+
+// RateLimitDecision is the outcome of checking a request against a rate limiter.
+type RateLimitDecision struct {
+	// Key identifies the client the limit applies to: 'apikey:<id>', 'jwt:<subject>' or 'ip:<address>'
+	// (also used for tokens without a subject).
+	Key     string
+	Allowed bool
+	// Remaining is the number of requests the client can still make straight away.
+	Remaining int
+	// RetryAfter is how long until the next request is allowed, if this one wasn't.
+	RetryAfter time.Duration
+}
+
+// GetRateLimitDecision returns the rate limiter decision of the request, if it went through a rate limiter.
+func GetRateLimitDecision(c *gin.Context) (RateLimitDecision, bool) {
+	value, ok := c.Get(rateLimitKey)
+	if !ok {
+		return RateLimitDecision{}, false
+	}
+
+	decision, ok := value.(RateLimitDecision)
+	return decision, ok
+}
+
+// bucket is the token bucket of a client.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// RateLimiter limits the rate of requests of each client with a token bucket: buckets hold up to Burst
+// tokens, refilled at Rate tokens per second, and every request takes one.
+type RateLimiter struct {
+	Rate  float64
+	Burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter returns a new RateLimiter allowing rate requests per second, in bursts of up to burst.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{Rate: rate, Burst: burst, buckets: make(map[string]*bucket)}
+}
+
+// Allow takes a token from the bucket of a client, if there's any left.
+func (l *RateLimiter) Allow(key string, now time.Time) RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.updated).Seconds()*l.Rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return RateLimitDecision{
+			Key:        key,
+			Allowed:    false,
+			RetryAfter: time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)),
+		}
+	}
+
+	b.tokens--
+	return RateLimitDecision{Key: key, Allowed: true, Remaining: int(b.tokens)}
+}
+
+// sweep drops the buckets that have refilled completely, as they're the same as new ones.
+// It runs at most once a minute. The lock must be held.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	fillTime := time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= fillTime {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimit returns a gin.HandlerFunc (middleware) that rejects requests of clients over the limit with a 429.
+// Clients are identified by their API key or token subject if authenticated (so it must run after
+// Authenticate), or by their IP address otherwise (gin.Context.ClientIP, see TrustedProxies).
+// Responses carry the X-RateLimit-Limit and X-RateLimit-Remaining headers, and Retry-After when rejected.
+func RateLimit(limiter *RateLimiter, respond ErrorResponder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if identity, ok := GetIdentity(c); ok {
+			switch {
+			case identity.Method == "apikey":
+				key = "apikey:" + identity.ID
+			case identity.Method == "jwt" && identity.Name != "":
+				key = "jwt:" + identity.Name
+			}
+		}
+
+		decision := limiter.Allow(key, time.Now())
+		c.Set(rateLimitKey, decision)
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limiter.Burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+
+		if !decision.Allowed {
+			retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			respond(c, 429, CodeRateLimited,
+				fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	limiter := middleware.NewRateLimiter(2, 3)
+
+	// Burst
+	for i := 2; i >= 0; i-- {
+		decision := limiter.Allow("ip:1.1.1.1", now)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, i, decision.Remaining)
+	}
+
+	decision := limiter.Allow("ip:1.1.1.1", now)
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, 500*time.Millisecond, decision.RetryAfter)
+
+	// Other clients have their own bucket
+	assert.True(t, limiter.Allow("ip:2.2.2.2", now).Allowed)
+
+	// Refill at the rate, up to the burst
+	assert.True(t, limiter.Allow("ip:1.1.1.1", now.Add(500*time.Millisecond)).Allowed)
+	assert.False(t, limiter.Allow("ip:1.1.1.1", now.Add(500*time.Millisecond)).Allowed)
+	assert.Equal(t, 2, limiter.Allow("ip:1.1.1.1", now.Add(time.Hour)).Remaining)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := map[string]struct {
+		identity     *middleware.Identity
+		forwardedFor string
+		expectedKey  string
+	}{
+		"anonymous":             {expectedKey: "ip:10.0.0.1"},
+		"forwarded for ignored": {forwardedFor: "1.2.3.4", expectedKey: "ip:10.0.0.1"},
+		"api key":               {identity: &middleware.Identity{Method: "apikey", ID: "7"}, expectedKey: "apikey:7"},
+		"token":                 {identity: &middleware.Identity{Method: "jwt", Name: "crawler"}, expectedKey: "jwt:crawler"},
+		"token without subject": {identity: &middleware.Identity{Method: "jwt"}, expectedKey: "ip:10.0.0.1"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var decision middleware.RateLimitDecision
+			router := gin.New()
+			router.Use(middleware.TrustedProxies(nil))
+			router.GET("/", func(c *gin.Context) {
+				if test.identity != nil {
+					middleware.SetIdentity(c, *test.identity)
+				}
+			}, middleware.RateLimit(middleware.NewRateLimiter(1, 1), nil), func(c *gin.Context) {
+				decision, _ = middleware.GetRateLimitDecision(c)
+			})
+
+			req, err := http.NewRequest("GET", "/", nil)
+			require.NoError(t, err)
+			req.RemoteAddr = "10.0.0.1:1234"
+			if test.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", test.forwardedFor)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.expectedKey, decision.Key)
+		})
+	}
+}
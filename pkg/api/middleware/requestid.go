@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// RequestIDHeader is the header holding the ID of a request, both in requests and responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the length above which request IDs sent by clients are replaced.
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key under which the request ID is stored.
+const requestIDKey = "requestid"
+
+// RequestID returns a gin.HandlerFunc (middleware) that gives every request an ID: the one in the X-Request-ID
+// header if valid, a random one otherwise. The ID is echoed in the response header and stored in the gin context.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request, or an empty string if it went through no RequestID middleware.
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// RequestLogger returns a logger adding the IDs of the request and its trace to every message.
+func RequestLogger(c *gin.Context, logger log.Logger) log.Logger {
+	var fields []log.FieldFunc
+	if requestID := GetRequestID(c); requestID != "" {
+		fields = append(fields, log.Field("requestid", requestID))
+	}
+	if traceID := GetTraceID(c); traceID != "" {
+		fields = append(fields, log.Field("traceid", traceID))
+	}
+
+	if len(fields) == 0 {
+		return logger
+	}
+	return log.WithFields(logger, fields...)
+}
+
+// validRequestID reports whether a request ID sent by a client can be used as is: not empty, not too long and
+// made of printable ASCII characters only, so that it can't forge log lines.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		// Unlikely, and a request ID isn't worth failing the request for
+		return "unknown"
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,79 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogger records the fields of the messages logged.
+type captureLogger struct {
+	fields []log.FieldsMap
+}
+
+func (l *captureLogger) log(fields []log.FieldFunc) {
+	fieldsMap := log.FieldsMap{}
+	for _, f := range fields {
+		f(fieldsMap)
+	}
+	l.fields = append(l.fields, fieldsMap)
+}
+
+func (l *captureLogger) Debug(msg string, fields ...log.FieldFunc) { l.log(fields) }
+func (l *captureLogger) Info(msg string, fields ...log.FieldFunc)  { l.log(fields) }
+func (l *captureLogger) Warn(msg string, fields ...log.FieldFunc)  { l.log(fields) }
+func (l *captureLogger) Error(msg string, fields ...log.FieldFunc) { l.log(fields) }
+
+func TestRequestID(t *testing.T) {
+	tests := map[string]struct {
+		requestID  string
+		expectSame bool
+	}{
+		"generated when absent":       {requestID: ""},
+		"kept when valid":             {requestID: "abc-123", expectSame: true},
+		"replaced when too long":      {requestID: strings.Repeat("a", 129)},
+		"replaced when not printable": {requestID: "abc\ndef"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := &captureLogger{}
+
+			gin.SetMode(gin.ReleaseMode)
+			router := gin.New()
+			router.Use(middleware.RequestID(), middleware.GinReqLogger(logger, "", "request served", ""))
+			router.GET("/", func(c *gin.Context) {
+				middleware.RequestLogger(c, logger).Error("handler error")
+				c.Status(200)
+			})
+
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/", nil)
+			require.NoError(t, err)
+			if test.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, test.requestID)
+			}
+			router.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(middleware.RequestIDHeader)
+			require.NotEmpty(t, requestID)
+			if test.expectSame {
+				assert.Equal(t, test.requestID, requestID)
+			} else {
+				assert.NotEqual(t, test.requestID, requestID)
+			}
+
+			// Both the handler's and the request log lines carry it
+			require.Len(t, logger.fields, 2)
+			assert.Equal(t, requestID, logger.fields[0]["requestid"])
+			assert.Equal(t, requestID, logger.fields[1]["requestid"])
+		})
+	}
+}
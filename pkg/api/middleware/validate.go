@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestValidator validates requests against an OpenAPI 3 document: their path and query parameters, headers
+// and bodies.
+type RequestValidator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+}
+
+// NewRequestValidator returns a new RequestValidator for the given OpenAPI 3 document, in JSON or YAML.
+func NewRequestValidator(spec []byte) (*RequestValidator, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %s", err)
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RequestValidator{
+		router: router,
+		// Credentials are checked by the Authenticator
+		options: &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}, nil
+}
+
+// Validate returns a gin.HandlerFunc (middleware) that rejects requests not matching the document with a 400.
+// Requests to routes missing from the document are let through.
+func (v *RequestValidator) Validate(respond ErrorResponder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, pathParams, err := v.router.FindRoute(c.Request)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(c.Request.Context(), &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    v.options,
+		})
+		if err != nil {
+			message, fieldErrors := validationError(err)
+			respond(c, 400, CodeValidationFailed, message, fieldErrors...)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// validationError returns a one line message describing a validation error, without the schemas and values
+// the error messages of the validator include, along with the field it's about, if any.
+func validationError(err error) (string, []FieldError) {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error(), nil
+	}
+
+	reason, code, path := requestErr.Reason, "invalid", ""
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		reason, code = schemaErr.Reason, schemaErr.SchemaField
+		path = strings.Join(schemaErr.JSONPointer(), ".")
+	} else if requestErr.Err != nil {
+		reason = requestErr.Err.Error()
+	}
+
+	var field, message string
+	switch {
+	case requestErr.Parameter != nil:
+		field = requestErr.Parameter.Name
+		message = fmt.Sprintf("%s parameter %s: %s", requestErr.Parameter.In, field, reason)
+	case requestErr.RequestBody != nil && path != "":
+		field = path
+		message = fmt.Sprintf("request body: %s: %s", field, reason)
+	case requestErr.RequestBody != nil:
+		return fmt.Sprintf("request body: %s", reason), nil
+	default:
+		return reason, nil
+	}
+
+	return message, []FieldError{{Field: field, Code: code, Message: reason}}
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	_ "embed"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec is the OpenAPI 3 document describing the API. It must be kept in sync with the routes and their
+// parameters, which TestOpenAPISpec checks.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec returns the OpenAPI 3 document describing the API, in JSON.
+func OpenAPISpec() []byte {
+	return openAPISpec
+}
+
+// GetOpenAPISpec handles requests to get the OpenAPI document describing the API.
+func (s *Server) GetOpenAPISpec(c *gin.Context) {
+	c.Data(200, "application/json", openAPISpec)
+}
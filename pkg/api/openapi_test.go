@@ -0,0 +1,336 @@
+package api_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/api/middleware"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+// handlerBindings holds the query parameters and JSON body fields a handler binds.
+type handlerBindings struct {
+	query        []string
+	body         []string
+	bodyRequired []string
+	bodyIsArray  bool
+}
+
+// handlerSources indexes the Server methods and struct and slice types of the api package, and the struct and
+// slice types of the entities package (as 'entities.Name'), tests excluded, to find out what each handler binds.
+type handlerSources struct {
+	methods map[string]*ast.FuncDecl
+	types   map[string]*ast.StructType
+	slices  map[string]*ast.ArrayType
+}
+
+func parseHandlerSources(t *testing.T) handlerSources {
+	sources := handlerSources{methods: map[string]*ast.FuncDecl{}, types: map[string]*ast.StructType{},
+		slices: map[string]*ast.ArrayType{}}
+	sources.parse(t, ".", "api", "")
+	sources.parse(t, "../core/entities", "entities", "entities.")
+
+	return sources
+}
+
+func (h handlerSources) parse(t *testing.T, dir string, pkg string, typePrefix string) {
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	for _, file := range pkgs[pkg].Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv != nil && typePrefix == "" {
+					h.methods[decl.Name.Name] = decl
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+						switch typ := typeSpec.Type.(type) {
+						case *ast.StructType:
+							h.types[typePrefix+typeSpec.Name.Name] = typ
+						case *ast.ArrayType:
+							h.slices[typePrefix+typeSpec.Name.Name] = typ
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// bindings returns what the method binds, following calls to other Server methods.
+func (h handlerSources) bindings(t *testing.T, method string) handlerBindings {
+	var b handlerBindings
+	h.collect(t, method, &b, map[string]bool{})
+	return b
+}
+
+func (h handlerSources) collect(t *testing.T, method string, b *handlerBindings, visited map[string]bool) {
+	decl, ok := h.methods[method]
+	if !ok || visited[method] {
+		return
+	}
+	visited[method] = true
+
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		switch sel.Sel.Name {
+		case "ShouldBindQuery", "ShouldBindJSON":
+			arg, ok := call.Args[0].(*ast.UnaryExpr)
+			require.True(t, ok, "%s binds something other than the address of a variable", method)
+			structType, isArray := h.variableType(t, decl, arg.X.(*ast.Ident).Name)
+			for _, field := range structType.Fields.List {
+				tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+				if sel.Sel.Name == "ShouldBindQuery" {
+					b.query = append(b.query, tag.Get("form"))
+					continue
+				}
+
+				b.body = append(b.body, tag.Get("json"))
+				b.bodyIsArray = isArray
+				if strings.Split(tag.Get("binding"), ",")[0] == "required" {
+					b.bodyRequired = append(b.bodyRequired, tag.Get("json"))
+				}
+			}
+		default:
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == decl.Recv.List[0].Names[0].Name {
+				h.collect(t, sel.Sel.Name, b, visited)
+			}
+		}
+		return true
+	})
+}
+
+// variableType returns the struct type of the composite literal a variable of the method is initialised with,
+// and whether it's a slice (or a slice type) of that type.
+func (h handlerSources) variableType(t *testing.T, decl *ast.FuncDecl, name string) (*ast.StructType, bool) {
+	var lit *ast.CompositeLit
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok {
+			return true
+		}
+		for i, lhs := range assign.Lhs {
+			if ident, ok := lhs.(*ast.Ident); ok && ident.Name == name {
+				lit, _ = assign.Rhs[i].(*ast.CompositeLit)
+			}
+		}
+		return lit == nil
+	})
+	require.NotNil(t, lit, "%s: %s isn't initialised with a composite literal", decl.Name.Name, name)
+
+	typ, isArray := lit.Type, false
+	if ident, ok := typ.(*ast.Ident); ok && h.slices[ident.Name] != nil {
+		typ = h.slices[ident.Name]
+	}
+	if array, ok := typ.(*ast.ArrayType); ok {
+		typ, isArray = array.Elt, true
+	}
+
+	switch typ := typ.(type) {
+	case *ast.StructType:
+		return typ, isArray
+	case *ast.Ident:
+		structType, ok := h.types[typ.Name]
+		require.True(t, ok, "%s: %s isn't a struct type of the package", decl.Name.Name, typ.Name)
+		return structType, isArray
+	case *ast.SelectorExpr:
+		name := typ.X.(*ast.Ident).Name + "." + typ.Sel.Name
+		structType, ok := h.types[name]
+		require.True(t, ok, "%s: %s isn't a struct type of the entities package", decl.Name.Name, name)
+		return structType, isArray
+	}
+
+	require.Fail(t, "unsupported type", "%s: %s", decl.Name.Name, name)
+	return nil, false
+}
+
+// handlerName returns the name of the Server method of a route, or an empty string for other handlers.
+func handlerName(route gin.RouteInfo) string {
+	const receiver = ".(*Server)."
+	i := strings.Index(route.Handler, receiver)
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(route.Handler[i+len(receiver):], "-fm")
+}
+
+var pathParamRegexp = regexp.MustCompile(`/:([^/]+)`)
+
+func sorted(values []string) []string {
+	values = append([]string{}, values...)
+	sort.Strings(values)
+	return values
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(api.OpenAPISpec())
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(loader.Context))
+
+	validator := middleware.NewJWTValidator("https://gateway.example", "articles-mgmt")
+	server := api.NewServer("", 9999, false, log.NullLogger{}, setupMockDB(),
+		api.WithAPIKeyAuth("admin key 1234567"),
+		api.WithJWTAuth(validator),
+		api.WithMetrics(metrics.New()),
+		api.WithLogLevelControl(core.NewAppLogger(zapcore.AddSync(io.Discard), log.INFO), 0))
+
+	sources := parseHandlerSources(t)
+	documented := map[string]bool{}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for _, route := range server.Routes() {
+		path := pathParamRegexp.ReplaceAllString(route.Path, "/{$1}")
+		key := route.Method + " " + path
+		if !assert.True(t, documented[key], "route %s is not documented", key) {
+			continue
+		}
+		delete(documented, key)
+
+		item := doc.Paths.Find(path)
+		operation := item.GetOperation(route.Method)
+		name := handlerName(route)
+		if name == "" {
+			continue
+		}
+		assert.Equal(t, name, operation.OperationID, key)
+
+		params := map[string][]string{}
+		for _, param := range append(item.Parameters, operation.Parameters...) {
+			params[param.Value.In] = append(params[param.Value.In], param.Value.Name)
+		}
+
+		var pathParams []string
+		for _, match := range pathParamRegexp.FindAllStringSubmatch(route.Path, -1) {
+			pathParams = append(pathParams, match[1])
+		}
+		assert.Equal(t, sorted(pathParams), sorted(params["path"]), "path parameters of %s", key)
+
+		bindings := sources.bindings(t, name)
+		assert.Equal(t, sorted(bindings.query), sorted(params["query"]), "query parameters of %s", key)
+
+		var body *openapi3.Schema
+		if operation.RequestBody != nil {
+			if content := operation.RequestBody.Value.Content.Get("application/json"); content != nil {
+				body = content.Schema.Value
+			}
+		}
+		if len(bindings.body) == 0 {
+			assert.Nil(t, body, "%s doesn't take a JSON body", key)
+			continue
+		}
+		if !assert.NotNil(t, body, "JSON body of %s is not documented", key) {
+			continue
+		}
+
+		if bindings.bodyIsArray {
+			assert.Equal(t, "array", body.Type, "JSON body of %s is an array", key)
+			body = body.Items.Value
+		}
+
+		var properties []string
+		for property := range body.Properties {
+			properties = append(properties, property)
+		}
+		assert.Equal(t, sorted(bindings.body), sorted(properties), "JSON body properties of %s", key)
+		assert.Equal(t, sorted(bindings.bodyRequired), sorted(body.Required), "required JSON body properties of %s",
+			key)
+	}
+
+	for key := range documented {
+		assert.Fail(t, "documented route doesn't exist", key)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	mockDB := setupMockDB()
+	mockDB.On("GetArticlesVersion", "", "", "desc", mock.Anything).Return(entities.ArticlesVersion{}, nil)
+	mockDB.On("GetArticles", "", "", "desc", 10, mock.Anything).Return(entities.Articles{}, nil)
+	mockDB.On("AddFeed", mock.Anything).Return(entities.Feed{ID: 1}, nil)
+
+	validator, err := middleware.NewRequestValidator(api.OpenAPISpec())
+	require.NoError(t, err)
+	server := api.NewServer("", 9999, false, log.NullLogger{}, mockDB, api.WithRequestValidation(validator))
+	router := server.Router
+
+	tests := map[string]struct {
+		method          string
+		rawURL          string
+		body            string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		"valid query": {method: "GET", rawURL: "/api/v1/articles?limit=10", expectedStatus: 200},
+		"limit too high": {method: "GET", rawURL: "/api/v1/articles?limit=500", expectedStatus: 400,
+			expectedMessage: "query parameter limit"},
+		"invalid sorting": {method: "GET", rawURL: "/api/v1/articles?sorting=up", expectedStatus: 400,
+			expectedMessage: "query parameter sorting"},
+		"valid body": {method: "POST", rawURL: "/api/v1/feeds",
+			body:           `{"url": "https://example.com/feed", "provider": "provider 1", "category": "category 1"}`,
+			expectedStatus: 201},
+		"missing property": {method: "POST", rawURL: "/api/v1/feeds",
+			body:           `{"url": "https://example.com/feed", "provider": "provider 1"}`,
+			expectedStatus: 400, expectedMessage: "request body"},
+		"invalid property": {method: "POST", rawURL: "/api/v1/feeds",
+			body:           `{"url": "https://example.com/feed", "provider": "provider 1", "category": 1}`,
+			expectedStatus: 400, expectedMessage: "request body"},
+		"invalid path parameter": {method: "GET", rawURL: "/api/v1/feeds/abc", expectedStatus: 400,
+			expectedMessage: "path parameter id"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest(test.method, test.rawURL, strings.NewReader(test.body))
+			require.NoError(t, err)
+			if test.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Content-Length", strconv.Itoa(len(test.body)))
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expectedStatus, w.Code, w.Body.String())
+			if test.expectedMessage != "" {
+				assert.Contains(t, w.Body.String(), test.expectedMessage)
+			}
+		})
+	}
+}
@@ -1,16 +1,32 @@
 package core
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 // AppLogger is the application logger.
+//
+// Its level can be changed at any time, either for good or for a while, after which it reverts to the level it
+// had before.
 type AppLogger struct {
-	Level          log.Level
+	// level is the log.Level messages are logged from, accessed atomically.
+	level          uint32
+	atom           zap.AtomicLevel
 	zapLogger      *zap.Logger
 	zapSugarLogger *zap.SugaredLogger
+
+	mu sync.Mutex
+	// revertTimer reverts the level to revertLevel at revertAt, if the level was changed for a while.
+	revertTimer *time.Timer
+	revertLevel log.Level
+	revertAt    time.Time
 }
 
 // NewAppLogger returns a new logger.
@@ -21,32 +37,32 @@ func NewAppLogger(ws zapcore.WriteSyncer, logLevel log.Level) *AppLogger {
 }
 
 // Debug logs a debug message.
-func (l AppLogger) Debug(msg string, fields ...log.FieldFunc) {
+func (l *AppLogger) Debug(msg string, fields ...log.FieldFunc) {
 	l.logGeneric(log.DEBUG, msg, fields...)
 }
 
 // Info logs an info message.
-func (l AppLogger) Info(msg string, fields ...log.FieldFunc) {
+func (l *AppLogger) Info(msg string, fields ...log.FieldFunc) {
 	l.logGeneric(log.INFO, msg, fields...)
 }
 
 // Warn logs a warning message.
-func (l AppLogger) Warn(msg string, fields ...log.FieldFunc) {
+func (l *AppLogger) Warn(msg string, fields ...log.FieldFunc) {
 	l.logGeneric(log.WARN, msg, fields...)
 }
 
 // Error logs an error message.
-func (l AppLogger) Error(msg string, fields ...log.FieldFunc) {
+func (l *AppLogger) Error(msg string, fields ...log.FieldFunc) {
 	l.logGeneric(log.ERROR, msg, fields...)
 }
 
 // logGeneric logs a generic message.
-func (l AppLogger) logGeneric(level log.Level, msg string, fields ...log.FieldFunc) {
+func (l *AppLogger) logGeneric(level log.Level, msg string, fields ...log.FieldFunc) {
 	if l.zapLogger == nil {
 		return
 	}
 
-	if l.Level <= level {
+	if l.Level() <= level {
 		if len(fields) != 0 {
 			newFields := make(map[string]interface{})
 			for _, f := range fields {
@@ -82,7 +98,7 @@ func (l AppLogger) logGeneric(level log.Level, msg string, fields ...log.FieldFu
 
 // setupLogger sets up Logger with all the relevant configuration params.
 func (l *AppLogger) setupLogger(ws zapcore.WriteSyncer, logLevel log.Level) {
-	atom := zap.NewAtomicLevel()
+	l.atom = zap.NewAtomicLevel()
 
 	encoderCfg := zap.NewProductionEncoderConfig()
 	encoderCfg.TimeKey = "timestamp"
@@ -92,37 +108,102 @@ func (l *AppLogger) setupLogger(ws zapcore.WriteSyncer, logLevel log.Level) {
 		zapcore.NewCore(
 			zapcore.NewJSONEncoder(encoderCfg),
 			zapcore.Lock(ws),
-			atom),
+			l.atom),
 		zap.AddCaller(),
 		zap.AddCallerSkip(2))
 
 	l.zapLogger = logger
 	l.zapSugarLogger = logger.Sugar()
-	l.setLogLevel(atom, logLevel)
+	l.setLogLevel(logLevel)
+}
+
+// Level returns the level messages are logged from.
+func (l *AppLogger) Level() log.Level {
+	return log.Level(atomic.LoadUint32(&l.level))
+}
+
+// SetLevel sets the level messages are logged from, cancelling any pending revert.
+func (l *AppLogger) SetLevel(level log.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cancelRevert()
+	l.setLogLevel(level)
+}
+
+// SetLevelFor sets the level messages are logged from for the given duration, after which it reverts to the
+// level set before. Changing the level again before then starts over from the level set before the first
+// change. It returns the time the level reverts at.
+func (l *AppLogger) SetLevelFor(level log.Level, duration time.Duration) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	revertLevel := l.Level()
+	if l.revertTimer != nil {
+		revertLevel = l.revertLevel
+		l.cancelRevert()
+	}
+	l.setLogLevel(level)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() { l.revert(timer) })
+	l.revertTimer, l.revertLevel, l.revertAt = timer, revertLevel, time.Now().Add(duration)
+	return l.revertAt
+}
+
+// PendingRevert returns the level the logger reverts to and when, if its level was changed for a while.
+func (l *AppLogger) PendingRevert() (level log.Level, at time.Time, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revertTimer == nil {
+		return 0, time.Time{}, false
+	}
+	return l.revertLevel, l.revertAt, true
+}
+
+// revert reverts the level changed for a while by SetLevelFor, unless timer was cancelled in the meantime.
+func (l *AppLogger) revert(timer *time.Timer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revertTimer != timer {
+		return
+	}
+	l.revertTimer = nil
+	l.setLogLevel(l.revertLevel)
+	l.Info(fmt.Sprintf("log level reverted to %s", l.revertLevel), log.Field("type", "logging"))
+}
+
+// cancelRevert cancels the pending revert, if any. The caller must hold l.mu.
+func (l *AppLogger) cancelRevert() {
+	if l.revertTimer != nil {
+		l.revertTimer.Stop()
+		l.revertTimer = nil
+	}
 }
 
 // setLogLevel sets the log level.
-func (l *AppLogger) setLogLevel(atom zap.AtomicLevel, logLevel log.Level) {
-	if logLevel == log.DEBUG {
-		atom.SetLevel(zap.DebugLevel)
-		l.Level = logLevel
-	} else if logLevel == log.INFO {
-		atom.SetLevel(zap.InfoLevel)
-		l.Level = logLevel
-	} else if logLevel == log.WARN {
-		atom.SetLevel(zap.WarnLevel)
-		l.Level = logLevel
-	} else if logLevel == log.ERROR {
-		atom.SetLevel(zap.ErrorLevel)
-		l.Level = logLevel
-	} else {
-		atom.SetLevel(zap.InfoLevel)
-		l.Level = log.INFO
-		l.Warn("log level unrecognised. Setting log level to Info.", nil)
+func (l *AppLogger) setLogLevel(logLevel log.Level) {
+	switch logLevel {
+	case log.DEBUG:
+		l.atom.SetLevel(zap.DebugLevel)
+	case log.INFO:
+		l.atom.SetLevel(zap.InfoLevel)
+	case log.WARN:
+		l.atom.SetLevel(zap.WarnLevel)
+	case log.ERROR:
+		l.atom.SetLevel(zap.ErrorLevel)
+	default:
+		l.atom.SetLevel(zap.InfoLevel)
+		atomic.StoreUint32(&l.level, uint32(log.INFO))
+		l.Warn("log level unrecognised. Setting log level to Info.")
+		return
 	}
+	atomic.StoreUint32(&l.level, uint32(logLevel))
 }
 
 // Sync syncs the logger, i.e., flushes any data in the buffer.
-func (l AppLogger) Sync() {
+func (l *AppLogger) Sync() {
 	l.zapLogger.Sync()
 }
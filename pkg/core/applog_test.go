@@ -0,0 +1,48 @@
+package core_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAppLoggerSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := core.NewAppLogger(zapcore.AddSync(&buf), log.INFO)
+
+	logger.Debug("hidden")
+	logger.SetLevel(log.DEBUG)
+	logger.Debug("shown")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "shown")
+	assert.Equal(t, log.DEBUG, logger.Level())
+}
+
+func TestAppLoggerSetLevelFor(t *testing.T) {
+	logger := core.NewAppLogger(zapcore.AddSync(&bytes.Buffer{}), log.WARN)
+
+	logger.SetLevelFor(log.INFO, time.Hour)
+	logger.SetLevelFor(log.DEBUG, 10*time.Millisecond)
+
+	// The level reverts to the one set before the first change
+	revertTo, _, ok := logger.PendingRevert()
+	assert.True(t, ok)
+	assert.Equal(t, log.WARN, revertTo)
+	assert.Equal(t, log.DEBUG, logger.Level())
+
+	assert.Eventually(t, func() bool { return logger.Level() == log.WARN }, time.Second, 5*time.Millisecond)
+	_, _, ok = logger.PendingRevert()
+	assert.False(t, ok)
+
+	// Setting the level for good cancels the revert
+	logger.SetLevelFor(log.DEBUG, 10*time.Millisecond)
+	logger.SetLevel(log.ERROR)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, log.ERROR, logger.Level())
+}
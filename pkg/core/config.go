@@ -1,114 +1,587 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
-	"os"
+	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/secrets"
 )
 
-// TODO: We would replace this with a proper config library like Viper.
-
 const AppPrefix = "NEWS_APP_ARTICLES_MGMT"
 
 // Configuration holds the entire configuration
 type Configuration struct {
-	Webserver WebserverConfiguration
-	Options   OptionsConfiguration
-	Database  DatabaseConfiguration
+	Webserver   WebserverConfiguration   `yaml:"webserver"`
+	Options     OptionsConfiguration     `yaml:"options"`
+	Database    DatabaseConfiguration    `yaml:"database"`
+	Ingestion   IngestionConfiguration   `yaml:"ingestion"`
+	Webhooks    WebhooksConfiguration    `yaml:"webhooks"`
+	Events      EventsConfiguration      `yaml:"events"`
+	Auth        AuthConfiguration        `yaml:"auth"`
+	RateLimit   RateLimitConfiguration   `yaml:"ratelimit"`
+	Metrics     MetricsConfiguration     `yaml:"metrics"`
+	Tracing     TracingConfiguration     `yaml:"tracing"`
+	Compression CompressionConfiguration `yaml:"compression"`
+	CORS        CORSConfiguration        `yaml:"cors"`
+	Secrets     SecretsConfiguration     `yaml:"secrets"`
+
+	// SecretProvider provides the secrets settings refer to ('secret://NAME'). It's set up by LoadConfig, as
+	// configured in Secrets, unless set beforehand.
+	SecretProvider SecretProvider `yaml:"-"`
+
+	// sources holds the settings given in the configuration file and as command-line flags.
+	sources configSources
 }
 
 // WebserverConfiguration holds configuration related to the webserver
 type WebserverConfiguration struct {
-	Host string
-	Port int
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// ValidateRequests rejects requests not matching the OpenAPI document of the API.
+	ValidateRequests bool `yaml:"validate_requests"`
+	// CacheControl holds the Cache-Control header of the responses of the article listings, by route.
+	CacheControl map[string]string `yaml:"cache_control"`
+	// DrainDelay is the time requests are still served for on shutdown, once the readiness probe fails.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies whose X-Forwarded-For header is trusted for
+	// the address of clients.
+	TrustedProxies []string `yaml:"trusted_proxies"`
 }
 
 // OptionsConfiguration holds general configuration
 type OptionsConfiguration struct {
 	// Development mode disables the panic recovery so we can see what was the actual problem.
 	// and also, enables pprof
-	DevMode bool
+	DevMode bool `yaml:"dev_mode"`
 
-	LogLevel log.Level
+	LogLevel log.Level `yaml:"log_level"`
+	// LogLevelRevertAfter is the time log level changes made through the admin API last for, unless they say
+	// otherwise (0 keeps them for good).
+	LogLevelRevertAfter time.Duration `yaml:"log_level_revert_after"`
 }
 
 // DatabaseConfiguration holds configuration related to the database
 type DatabaseConfiguration struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
-	DBName   string
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	DBName   string `yaml:"dbname"`
+}
+
+// IngestionConfiguration holds configuration related to the feed poller
+type IngestionConfiguration struct {
+	// Feeds added to the feed registry on startup.
+	Feeds []FeedConfiguration `yaml:"feeds"`
+	// PollInterval is the poll interval of feeds that don't set their own.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// MaxBackoff caps the delay between retries of failing feeds.
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+	// MaxFailures is the number of consecutive failures after which a feed is disabled (0 means never).
+	MaxFailures int `yaml:"max_failures"`
+}
+
+// WebhooksConfiguration holds configuration related to webhook deliveries
+type WebhooksConfiguration struct {
+	// MaxAttempts is the number of attempts after which a delivery is marked as failed.
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+// EventsConfiguration holds configuration related to article lifecycle events
+type EventsConfiguration struct {
+	// Sink is where events are published, besides webhooks and server-sent events: 'none' or 'file'.
+	Sink string `yaml:"sink"`
+	// FilePath is the NDJSON file events are appended to, when Sink is 'file'.
+	FilePath string `yaml:"file_path,omitempty"`
+	// Retention is how long events are kept in the outbox once relayed (0 keeps them for good).
+	Retention time.Duration `yaml:"retention"`
+}
+
+// AuthConfiguration holds configuration related to authentication
+type AuthConfiguration struct {
+	// APIKeys requires an API key on write endpoints. It needs an admin key or JWT authentication to manage
+	// the keys.
+	APIKeys bool `yaml:"api_keys"`
+	// AdminKey authenticates requests to the admin endpoints, which are disabled if empty.
+	AdminKey string           `yaml:"admin_key,omitempty"`
+	JWT      JWTConfiguration `yaml:"jwt"`
+}
+
+// JWTConfiguration holds configuration related to JWT authentication, enabled when any key is given
+type JWTConfiguration struct {
+	Issuer   string `yaml:"issuer,omitempty"`
+	Audience string `yaml:"audience,omitempty"`
+	// HMACSecret verifies HS256 tokens.
+	HMACSecret string `yaml:"hmac_secret,omitempty"`
+	// PublicKeyFile is a PEM encoded RSA public key verifying RS256 tokens.
+	PublicKeyFile string `yaml:"public_key_file,omitempty"`
+	// JWKSFile is a JSON Web Key Set file with RSA and/or HMAC keys.
+	JWKSFile string `yaml:"jwks_file,omitempty"`
+}
+
+// Enabled reports whether JWT authentication is enabled.
+func (c JWTConfiguration) Enabled() bool {
+	return c.HMACSecret != "" || c.PublicKeyFile != "" || c.JWKSFile != ""
+}
+
+// RateLimitConfiguration holds configuration related to rate limiting, per client
+type RateLimitConfiguration struct {
+	Read  RateLimitRouteConfiguration `yaml:"read"`
+	Write RateLimitRouteConfiguration `yaml:"write"`
+}
+
+// RateLimitRouteConfiguration holds the rate limit of a kind of route
+type RateLimitRouteConfiguration struct {
+	// Rate is the number of requests per second allowed on average (0 means unlimited).
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of requests allowed at once.
+	Burst int `yaml:"burst"`
+}
+
+// MetricsConfiguration holds configuration related to the metrics endpoint
+type MetricsConfiguration struct {
+	// Enabled exposes metrics at /metrics.
+	Enabled bool `yaml:"enabled"`
+}
+
+// TracingConfiguration holds configuration related to tracing
+type TracingConfiguration struct {
+	// Exporter is where spans are sent: 'none', 'stdout' or 'otlp'.
+	Exporter string `yaml:"exporter"`
+	// OTLPEndpoint is the host:port of the OpenTelemetry collector, when Exporter is 'otlp'.
+	OTLPEndpoint string `yaml:"otlp_endpoint,omitempty"`
+	// OTLPInsecure sends spans over plain HTTP rather than HTTPS.
+	OTLPInsecure bool `yaml:"otlp_insecure"`
+	// SampleRatio is the fraction of the traces started by the service that are sampled.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+// CompressionConfiguration holds configuration related to the compression of responses
+type CompressionConfiguration struct {
+	// Enabled compresses responses (and accepts compressed batches of articles).
+	Enabled bool `yaml:"enabled"`
+	// MinSize is the size in bytes from which responses are compressed.
+	MinSize int `yaml:"min_size"`
+}
+
+// CORSConfiguration holds configuration related to cross-origin requests, allowed when any origin is given
+type CORSConfiguration struct {
+	// AllowedOrigins are the origins allowed, '*' allowing any.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	AllowedMethods []string `yaml:"allowed_methods"`
+	AllowedHeaders []string `yaml:"allowed_headers"`
+	// MaxAge is the number of seconds browsers can cache preflight responses for.
+	MaxAge int `yaml:"max_age"`
+}
+
+// Enabled reports whether cross-origin requests are allowed.
+func (c CORSConfiguration) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// SecretsConfiguration holds configuration related to secrets
+type SecretsConfiguration struct {
+	// Provider provides the secrets settings refer to: 'none' or 'file'.
+	Provider string `yaml:"provider"`
+	// File is the encrypted secrets file and KeyFile the file holding its key, for the file provider.
+	File    string `yaml:"file,omitempty"`
+	KeyFile string `yaml:"key_file,omitempty"`
+	// RefreshInterval is the interval the database credentials are read again at, to pick up rotated ones.
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// FeedConfiguration holds the configuration of a single feed to poll
+type FeedConfiguration struct {
+	URL      string `json:"url" yaml:"url"`
+	Provider string `json:"provider" yaml:"provider"`
+	Category string `json:"category" yaml:"category"`
 }
 
 // NewConfig returns new default configuration
 func NewConfig() (config Configuration) {
+	config.sources.flags = make(map[string]string)
 	config.setDefaults()
 	return config
 }
 
-// LoadConfig loads and validates config (from env vars)
-func (config *Configuration) LoadConfig() (err error) {
+// LoadConfig loads and validates config, from (in order of precedence) command-line flags registered with
+// RegisterFlags, environment variables and the configuration file, if any.
+// All the problems found are reported at once, as a ConfigErrors.
+func (config *Configuration) LoadConfig() error {
+	var err error
+	var errs ConfigErrors
 
-	if webserverHost, ok := os.LookupEnv(AppPrefix + "_WEBSERVER_HOST"); ok {
+	// lookup returns the value of a setting, recording the errors reading it (it's then left out)
+	lookup := func(name string) (string, bool) {
+		value, ok, err := config.resolve(name)
+		if err != nil {
+			errs.addf("configuration error: [%s] %s", strings.ReplaceAll(strings.ToLower(name), "_", " "), err)
+			return "", false
+		}
+		return value, ok
+	}
+
+	if configFile, ok := config.configFile(); ok {
+		if err = config.readFile(configFile); err != nil {
+			errs.addf("configuration error: [config file] %s", err)
+		}
+	}
+
+	// The secret provider is set up first, as the other settings can refer to its secrets
+	if secretsProvider, ok := lookup("SECRETS_PROVIDER"); ok {
+		if secretsProvider != "none" && secretsProvider != "file" {
+			errs.addf("configuration error: [secrets provider] input not allowed <%s>", secretsProvider)
+		} else {
+			config.Secrets.Provider = secretsProvider
+		}
+	}
+
+	if secretsFile, ok := lookup("SECRETS_FILE"); ok {
+		config.Secrets.File = secretsFile
+	}
+
+	if secretsKeyFile, ok := lookup("SECRETS_KEY_FILE"); ok {
+		config.Secrets.KeyFile = secretsKeyFile
+	}
+
+	if refreshInterval, ok := lookup("SECRETS_REFRESH_INTERVAL"); ok {
+		config.Secrets.RefreshInterval, err = time.ParseDuration(refreshInterval)
+		if err != nil || config.Secrets.RefreshInterval < 0 {
+			errs.addf("configuration error: [secrets refreshinterval] input not allowed <%s>", refreshInterval)
+		}
+	}
+
+	if config.Secrets.Provider == "file" && config.SecretProvider == nil {
+		if config.Secrets.File == "" || config.Secrets.KeyFile == "" {
+			errs.addf("configuration error: [secrets] file and keyfile are mandatory with the file provider")
+		} else if key, err := secrets.ReadKeyFile(config.Secrets.KeyFile); err != nil {
+			errs.addf("configuration error: [secrets keyfile] %s", err)
+		} else if config.SecretProvider, err = secrets.NewFileProvider(config.Secrets.File, key); err != nil {
+			errs.addf("configuration error: [secrets file] %s", err)
+		}
+	}
+
+	if webserverHost, ok := lookup("WEBSERVER_HOST"); ok {
 		config.Webserver.Host = webserverHost
 	}
 
-	if webserverPort, ok := os.LookupEnv(AppPrefix + "_WEBSERVER_PORT"); ok {
+	if webserverPort, ok := lookup("WEBSERVER_PORT"); ok {
 		config.Webserver.Port, err = strconv.Atoi(webserverPort)
 		if err != nil || config.Webserver.Port <= 0 || config.Webserver.Port > 1<<16-1 {
-			return fmt.Errorf("configuration error: [webserver port] input not allowed <%s>", webserverPort)
+			errs.addf("configuration error: [webserver port] input not allowed <%s>", webserverPort)
+		}
+	}
+
+	if validateRequests, ok := lookup("WEBSERVER_VALIDATE_REQUESTS"); ok {
+		config.Webserver.ValidateRequests, err = strconv.ParseBool(validateRequests)
+		if err != nil {
+			errs.addf("configuration error: [webserver validaterequests] unrecognizable boolean <%s>",
+				validateRequests)
+		}
+	}
+
+	if drainDelay, ok := lookup("WEBSERVER_DRAIN_DELAY"); ok {
+		config.Webserver.DrainDelay, err = time.ParseDuration(drainDelay)
+		if err != nil || config.Webserver.DrainDelay < 0 {
+			errs.addf("configuration error: [webserver draindelay] input not allowed <%s>", drainDelay)
+		}
+	}
+
+	if trustedProxies, ok := lookup("WEBSERVER_TRUSTED_PROXIES"); ok {
+		config.Webserver.TrustedProxies = splitList(trustedProxies)
+		for _, proxy := range config.Webserver.TrustedProxies {
+			if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+				errs.addf("configuration error: [webserver trustedproxies] input not allowed <%s>", proxy)
+			}
+		}
+	}
+
+	// Cache control is given as a JSON object mapping routes to their Cache-Control header
+	if cacheControl, ok := lookup("WEBSERVER_CACHE_CONTROL"); ok {
+		config.Webserver.CacheControl = nil
+		err = json.Unmarshal([]byte(cacheControl), &config.Webserver.CacheControl)
+		if err != nil {
+			errs.addf("configuration error: [webserver cachecontrol] invalid JSON: %s", err)
+		}
+
+		for route := range config.Webserver.CacheControl {
+			if !strings.HasPrefix(route, "/") {
+				errs.addf("configuration error: [webserver cachecontrol] input not allowed <%s>", route)
+			}
 		}
 	}
 
-	if devMode, ok := os.LookupEnv(AppPrefix + "_OPTIONS_DEV_MODE"); ok {
+	if devMode, ok := lookup("OPTIONS_DEV_MODE"); ok {
 		config.Options.DevMode, err = strconv.ParseBool(devMode)
 		if err != nil {
-			return fmt.Errorf("configuration error: [options devmode] unrecognizable boolean <%s>", devMode)
+			errs.addf("configuration error: [options devmode] unrecognizable boolean <%s>", devMode)
 		}
 	}
 
-	if logLevel, ok := os.LookupEnv(AppPrefix + "_OPTIONS_LOG_LEVEL"); ok {
+	if logLevel, ok := lookup("OPTIONS_LOG_LEVEL"); ok {
 		config.Options.LogLevel, err = ParseLogLevel(logLevel)
 		if err != nil {
-			return fmt.Errorf("configuration error: [options loglevel] unrecognized log level")
+			errs.addf("configuration error: [options loglevel] unrecognized log level")
+		}
+	}
+
+	if revertAfter, ok := lookup("OPTIONS_LOG_LEVEL_REVERT_AFTER"); ok {
+		config.Options.LogLevelRevertAfter, err = time.ParseDuration(revertAfter)
+		if err != nil || config.Options.LogLevelRevertAfter < 0 {
+			errs.addf("configuration error: [options loglevelrevertafter] input not allowed <%s>", revertAfter)
+		}
+	}
+
+	// Feeds are given as a JSON array of objects with the url, provider and category keys
+	if feeds, ok := lookup("INGESTION_FEEDS"); ok {
+		err = json.Unmarshal([]byte(feeds), &config.Ingestion.Feeds)
+		if err != nil {
+			errs.addf("configuration error: [ingestion feeds] invalid JSON: %s", err)
+		}
+
+		for _, feed := range config.Ingestion.Feeds {
+			if feed.URL == "" || feed.Provider == "" || feed.Category == "" {
+				errs.addf("configuration error: [ingestion feeds] url, provider and category are mandatory")
+				break
+			}
+		}
+	}
+
+	if pollInterval, ok := lookup("INGESTION_POLL_INTERVAL"); ok {
+		config.Ingestion.PollInterval, err = time.ParseDuration(pollInterval)
+		if err != nil || config.Ingestion.PollInterval <= 0 {
+			errs.addf("configuration error: [ingestion pollinterval] input not allowed <%s>", pollInterval)
+		}
+	}
+
+	if maxBackoff, ok := lookup("INGESTION_MAX_BACKOFF"); ok {
+		config.Ingestion.MaxBackoff, err = time.ParseDuration(maxBackoff)
+		if err != nil || config.Ingestion.MaxBackoff <= 0 {
+			errs.addf("configuration error: [ingestion maxbackoff] input not allowed <%s>", maxBackoff)
+		}
+	}
+
+	if maxFailures, ok := lookup("INGESTION_MAX_FAILURES"); ok {
+		config.Ingestion.MaxFailures, err = strconv.Atoi(maxFailures)
+		if err != nil || config.Ingestion.MaxFailures < 0 {
+			errs.addf("configuration error: [ingestion maxfailures] input not allowed <%s>", maxFailures)
+		}
+	}
+
+	if maxAttempts, ok := lookup("WEBHOOKS_MAX_ATTEMPTS"); ok {
+		config.Webhooks.MaxAttempts, err = strconv.Atoi(maxAttempts)
+		if err != nil || config.Webhooks.MaxAttempts < 1 {
+			errs.addf("configuration error: [webhooks maxattempts] input not allowed <%s>", maxAttempts)
+		}
+	}
+
+	if eventsSink, ok := lookup("EVENTS_SINK"); ok {
+		if eventsSink != "none" && eventsSink != "file" {
+			errs.addf("configuration error: [events sink] input not allowed <%s>", eventsSink)
+		} else {
+			config.Events.Sink = eventsSink
+		}
+	}
+
+	if eventsFilePath, ok := lookup("EVENTS_FILE_PATH"); ok {
+		config.Events.FilePath = eventsFilePath
+	}
+
+	if eventsRetention, ok := lookup("EVENTS_RETENTION"); ok {
+		config.Events.Retention, err = time.ParseDuration(eventsRetention)
+		if err != nil || config.Events.Retention < 0 {
+			errs.addf("configuration error: [events retention] input not allowed <%s>", eventsRetention)
+		}
+	}
+
+	if config.Events.Sink == "file" && config.Events.FilePath == "" {
+		errs.addf("configuration error: [events filepath] mandatory config parameter missing")
+	}
+
+	if apiKeys, ok := lookup("AUTH_API_KEYS"); ok {
+		config.Auth.APIKeys, err = strconv.ParseBool(apiKeys)
+		if err != nil {
+			errs.addf("configuration error: [auth apikeys] unrecognizable boolean <%s>", apiKeys)
+		}
+	}
+
+	if adminKey, ok := lookup("AUTH_ADMIN_KEY"); ok {
+		if len(adminKey) < 16 {
+			errs.addf("configuration error: [auth adminkey] must be at least 16 characters long")
+		}
+		config.Auth.AdminKey = adminKey
+	}
+
+	if jwtIssuer, ok := lookup("AUTH_JWT_ISSUER"); ok {
+		config.Auth.JWT.Issuer = jwtIssuer
+	}
+
+	if jwtAudience, ok := lookup("AUTH_JWT_AUDIENCE"); ok {
+		config.Auth.JWT.Audience = jwtAudience
+	}
+
+	if jwtHMACSecret, ok := lookup("AUTH_JWT_HMAC_SECRET"); ok {
+		if len(jwtHMACSecret) < 32 {
+			errs.addf("configuration error: [auth jwt hmacsecret] must be at least 32 characters long")
 		}
+		config.Auth.JWT.HMACSecret = jwtHMACSecret
 	}
 
-	if dbHost, ok := os.LookupEnv(AppPrefix + "_DATABASE_HOST"); ok {
+	if jwtPublicKeyFile, ok := lookup("AUTH_JWT_PUBLIC_KEY_FILE"); ok {
+		config.Auth.JWT.PublicKeyFile = jwtPublicKeyFile
+	}
+
+	if jwtJWKSFile, ok := lookup("AUTH_JWT_JWKS_FILE"); ok {
+		config.Auth.JWT.JWKSFile = jwtJWKSFile
+	}
+
+	if config.Auth.JWT.Enabled() && (config.Auth.JWT.Issuer == "" || config.Auth.JWT.Audience == "") {
+		errs.addf("configuration error: [auth jwt] issuer and audience are mandatory")
+	}
+
+	// Without an admin key nor JWT authentication, no API key could ever be created and writes would be locked
+	if config.Auth.APIKeys && config.Auth.AdminKey == "" && !config.Auth.JWT.Enabled() {
+		errs.addf("configuration error: [auth apikeys] API keys can't be managed without an admin key or JWT authentication")
+	}
+
+	if readRate, ok := lookup("RATELIMIT_READ_RATE"); ok {
+		config.RateLimit.Read.Rate, err = strconv.ParseFloat(readRate, 64)
+		if err != nil || config.RateLimit.Read.Rate < 0 {
+			errs.addf("configuration error: [ratelimit read rate] input not allowed <%s>", readRate)
+		}
+	}
+
+	if readBurst, ok := lookup("RATELIMIT_READ_BURST"); ok {
+		config.RateLimit.Read.Burst, err = strconv.Atoi(readBurst)
+		if err != nil || config.RateLimit.Read.Burst < 1 {
+			errs.addf("configuration error: [ratelimit read burst] input not allowed <%s>", readBurst)
+		}
+	}
+
+	if writeRate, ok := lookup("RATELIMIT_WRITE_RATE"); ok {
+		config.RateLimit.Write.Rate, err = strconv.ParseFloat(writeRate, 64)
+		if err != nil || config.RateLimit.Write.Rate < 0 {
+			errs.addf("configuration error: [ratelimit write rate] input not allowed <%s>", writeRate)
+		}
+	}
+
+	if writeBurst, ok := lookup("RATELIMIT_WRITE_BURST"); ok {
+		config.RateLimit.Write.Burst, err = strconv.Atoi(writeBurst)
+		if err != nil || config.RateLimit.Write.Burst < 1 {
+			errs.addf("configuration error: [ratelimit write burst] input not allowed <%s>", writeBurst)
+		}
+	}
+
+	if metricsEnabled, ok := lookup("METRICS_ENABLED"); ok {
+		config.Metrics.Enabled, err = strconv.ParseBool(metricsEnabled)
+		if err != nil {
+			errs.addf("configuration error: [metrics enabled] unrecognizable boolean <%s>", metricsEnabled)
+		}
+	}
+
+	if tracingExporter, ok := lookup("TRACING_EXPORTER"); ok {
+		if tracingExporter != "none" && tracingExporter != "stdout" && tracingExporter != "otlp" {
+			errs.addf("configuration error: [tracing exporter] input not allowed <%s>", tracingExporter)
+		} else {
+			config.Tracing.Exporter = tracingExporter
+		}
+	}
+
+	if otlpEndpoint, ok := lookup("TRACING_OTLP_ENDPOINT"); ok {
+		config.Tracing.OTLPEndpoint = otlpEndpoint
+	}
+
+	if otlpInsecure, ok := lookup("TRACING_OTLP_INSECURE"); ok {
+		config.Tracing.OTLPInsecure, err = strconv.ParseBool(otlpInsecure)
+		if err != nil {
+			errs.addf("configuration error: [tracing otlp insecure] unrecognizable boolean <%s>", otlpInsecure)
+		}
+	}
+
+	if sampleRatio, ok := lookup("TRACING_SAMPLE_RATIO"); ok {
+		config.Tracing.SampleRatio, err = strconv.ParseFloat(sampleRatio, 64)
+		if err != nil || config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+			errs.addf("configuration error: [tracing sample ratio] input not allowed <%s>", sampleRatio)
+		}
+	}
+
+	if compressionEnabled, ok := lookup("COMPRESSION_ENABLED"); ok {
+		config.Compression.Enabled, err = strconv.ParseBool(compressionEnabled)
+		if err != nil {
+			errs.addf("configuration error: [compression enabled] unrecognizable boolean <%s>",
+				compressionEnabled)
+		}
+	}
+
+	if compressionMinSize, ok := lookup("COMPRESSION_MIN_SIZE"); ok {
+		config.Compression.MinSize, err = strconv.Atoi(compressionMinSize)
+		if err != nil || config.Compression.MinSize < 0 {
+			errs.addf("configuration error: [compression minsize] input not allowed <%s>", compressionMinSize)
+		}
+	}
+
+	// CORS lists are comma separated
+	if allowedOrigins, ok := lookup("CORS_ALLOWED_ORIGINS"); ok {
+		config.CORS.AllowedOrigins = splitList(allowedOrigins)
+	}
+
+	if allowedMethods, ok := lookup("CORS_ALLOWED_METHODS"); ok {
+		config.CORS.AllowedMethods = splitList(allowedMethods)
+		if len(config.CORS.AllowedMethods) == 0 {
+			errs.addf("configuration error: [cors allowedmethods] input not allowed <%s>", allowedMethods)
+		}
+	}
+
+	if allowedHeaders, ok := lookup("CORS_ALLOWED_HEADERS"); ok {
+		config.CORS.AllowedHeaders = splitList(allowedHeaders)
+	}
+
+	if maxAge, ok := lookup("CORS_MAX_AGE"); ok {
+		config.CORS.MaxAge, err = strconv.Atoi(maxAge)
+		if err != nil || config.CORS.MaxAge < 0 {
+			errs.addf("configuration error: [cors maxage] input not allowed <%s>", maxAge)
+		}
+	}
+
+	if dbHost, ok := lookup("DATABASE_HOST"); ok {
 		config.Database.Host = dbHost
 	} else {
-		return fmt.Errorf("configuration error: [database host] mandatory config parameter missing")
+		errs.addf("configuration error: [database host] mandatory config parameter missing")
 	}
 
-	if dbPort, ok := os.LookupEnv(AppPrefix + "_DATABASE_PORT"); ok {
+	if dbPort, ok := lookup("DATABASE_PORT"); ok {
 		config.Database.Port, err = strconv.Atoi(dbPort)
 		if err != nil || config.Database.Port <= 0 || config.Database.Port > 1<<16-1 {
-			return fmt.Errorf("configuration error: [database port] input not allowed <%s>", dbPort)
+			errs.addf("configuration error: [database port] input not allowed <%s>", dbPort)
 		}
 	}
 
-	if dbUsername, ok := os.LookupEnv(AppPrefix + "_DATABASE_USERNAME"); ok {
+	if dbUsername, ok := lookup("DATABASE_USERNAME"); ok {
 		config.Database.Username = dbUsername
 	} else {
-		return fmt.Errorf("configuration error: [database username] mandatory config parameter missing")
+		errs.addf("configuration error: [database username] mandatory config parameter missing")
 	}
 
-	if dbPassword, ok := os.LookupEnv(AppPrefix + "_DATABASE_PASSWORD"); ok {
+	if dbPassword, ok := lookup("DATABASE_PASSWORD"); ok {
 		config.Database.Password = dbPassword
 	} else {
-		return fmt.Errorf("configuration error: [database password] mandatory config parameter missing")
+		errs.addf("configuration error: [database password] mandatory config parameter missing")
 	}
 
-	if dbName, ok := os.LookupEnv(AppPrefix + "_DATABASE_DBNAME"); ok {
+	if dbName, ok := lookup("DATABASE_DBNAME"); ok {
 		config.Database.DBName = dbName
 	} else {
-		return fmt.Errorf("configuration error: [database dbname] mandatory config parameter missing")
+		errs.addf("configuration error: [database dbname] mandatory config parameter missing")
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
@@ -117,13 +590,73 @@ func (config *Configuration) setDefaults() {
 	// Webserver
 	config.Webserver.Host = "127.0.0.1"
 	config.Webserver.Port = 8080
+	// Long enough for load balancers to notice the failing readiness probe
+	config.Webserver.DrainDelay = 5 * time.Second
+	// Clients can keep article listings but must revalidate them
+	config.Webserver.CacheControl = map[string]string{
+		"/api/v1/articles":      "no-cache",
+		"/api/v1/articles.rss":  "no-cache",
+		"/api/v1/articles.atom": "no-cache",
+	}
 
 	// Options
 	config.Options.DevMode = false
 	config.Options.LogLevel = log.INFO
+	config.Options.LogLevelRevertAfter = 30 * time.Minute
 
 	// Database
 	config.Database.Port = 3306
+
+	// Ingestion
+	config.Ingestion.PollInterval = 15 * time.Minute
+	config.Ingestion.MaxBackoff = 24 * time.Hour
+	config.Ingestion.MaxFailures = 10
+
+	// Webhooks
+	config.Webhooks.MaxAttempts = 8
+
+	// Events
+	config.Events.Sink = "none"
+	config.Events.Retention = 7 * 24 * time.Hour
+
+	// Rate limit
+	config.RateLimit.Read.Rate = 10
+	config.RateLimit.Read.Burst = 20
+	config.RateLimit.Write.Rate = 5
+	config.RateLimit.Write.Burst = 10
+
+	// Metrics
+	// /metrics is unauthenticated, so it's only exposed on request
+	config.Metrics.Enabled = false
+
+	// Tracing
+	config.Tracing.Exporter = "none"
+	config.Tracing.SampleRatio = 1
+
+	// Compression
+	config.Compression.Enabled = true
+	config.Compression.MinSize = 1024
+
+	// CORS
+	config.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "DELETE"}
+	config.CORS.AllowedHeaders = []string{"Authorization", "Content-Type", "Content-Encoding", "X-API-Key",
+		"X-Request-ID", "If-None-Match", "If-Modified-Since", "Last-Event-ID"}
+	config.CORS.MaxAge = 600
+
+	// Secrets
+	config.Secrets.Provider = "none"
+	config.Secrets.RefreshInterval = time.Minute
+}
+
+// splitList splits a comma separated list, leaving out empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // ParseLogLevel parses a string and returns a log level enum.
@@ -0,0 +1,376 @@
+package core
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// setting is a configuration parameter, named after its environment variable (without the AppPrefix).
+// In configuration files, it's the key path joined by '_' (e.g. 'webserver: {port: 8080}' for 'WEBSERVER_PORT'),
+// and its command-line flag is its name in lowercase with '-' instead of '_' (e.g. '--webserver-port').
+type setting struct {
+	name  string
+	usage string
+	// structured settings are given as JSON in environment variables and flags, and as they are in files.
+	structured bool
+	// secret settings can also be read from a file, given in their '_FILE' variant, and are redacted when printed.
+	// They have no command-line flag, as the command line can be read by other users: only their variant does.
+	secret bool
+}
+
+// settings lists the configuration parameters.
+var settings = []setting{
+	{name: "WEBSERVER_HOST", usage: "address to listen on"},
+	{name: "WEBSERVER_PORT", usage: "port to listen on"},
+	{name: "WEBSERVER_VALIDATE_REQUESTS", usage: "validate requests against the OpenAPI document"},
+	{name: "WEBSERVER_DRAIN_DELAY", usage: "time requests are still served for on shutdown"},
+	{name: "WEBSERVER_TRUSTED_PROXIES", usage: "addresses or CIDR ranges of the proxies trusted to set X-Forwarded-For"},
+	{name: "WEBSERVER_CACHE_CONTROL", usage: "Cache-Control header of the article listings, by route", structured: true},
+	{name: "OPTIONS_DEV_MODE", usage: "development mode (no panic recovery, pprof)"},
+	{name: "OPTIONS_LOG_LEVEL", usage: "log level: debug, info, warning or error"},
+	{name: "OPTIONS_LOG_LEVEL_REVERT_AFTER", usage: "time log level changes made through the admin API last for (0 for good)"},
+	{name: "INGESTION_FEEDS", usage: "feeds added to the feed registry on startup", structured: true},
+	{name: "INGESTION_POLL_INTERVAL", usage: "poll interval of feeds that don't set their own"},
+	{name: "INGESTION_MAX_BACKOFF", usage: "maximum delay between retries of failing feeds"},
+	{name: "INGESTION_MAX_FAILURES", usage: "consecutive failures after which a feed is disabled"},
+	{name: "WEBHOOKS_MAX_ATTEMPTS", usage: "attempts after which a webhook delivery is marked as failed"},
+	{name: "EVENTS_SINK", usage: "where article events are published: none or file"},
+	{name: "EVENTS_FILE_PATH", usage: "file events are appended to"},
+	{name: "EVENTS_RETENTION", usage: "how long relayed events are kept (0 keeps them)"},
+	{name: "AUTH_API_KEYS", usage: "require an API key on write endpoints (needs an admin key or JWT authentication)"},
+	{name: "AUTH_ADMIN_KEY", usage: "key authenticating requests to the admin endpoints", secret: true},
+	{name: "AUTH_JWT_ISSUER", usage: "issuer of the JWTs"},
+	{name: "AUTH_JWT_AUDIENCE", usage: "audience of the JWTs"},
+	{name: "AUTH_JWT_HMAC_SECRET", usage: "secret verifying HS256 tokens", secret: true},
+	{name: "AUTH_JWT_PUBLIC_KEY_FILE", usage: "PEM encoded RSA public key verifying RS256 tokens"},
+	{name: "AUTH_JWT_JWKS_FILE", usage: "JSON Web Key Set file"},
+	{name: "RATELIMIT_READ_RATE", usage: "requests per second allowed on read endpoints (0 disables)"},
+	{name: "RATELIMIT_READ_BURST", usage: "requests allowed at once on read endpoints"},
+	{name: "RATELIMIT_WRITE_RATE", usage: "requests per second allowed on write endpoints (0 disables)"},
+	{name: "RATELIMIT_WRITE_BURST", usage: "requests allowed at once on write endpoints"},
+	{name: "METRICS_ENABLED", usage: "expose metrics at /metrics (unauthenticated)"},
+	{name: "TRACING_EXPORTER", usage: "where spans are sent: none, stdout or otlp"},
+	{name: "TRACING_OTLP_ENDPOINT", usage: "host:port of the OpenTelemetry collector"},
+	{name: "TRACING_OTLP_INSECURE", usage: "send spans over plain HTTP"},
+	{name: "TRACING_SAMPLE_RATIO", usage: "fraction of the traces sampled"},
+	{name: "COMPRESSION_ENABLED", usage: "compress responses"},
+	{name: "COMPRESSION_MIN_SIZE", usage: "size in bytes from which responses are compressed"},
+	{name: "CORS_ALLOWED_ORIGINS", usage: "origins allowed to make cross-origin requests (comma separated)"},
+	{name: "CORS_ALLOWED_METHODS", usage: "methods allowed in cross-origin requests (comma separated)"},
+	{name: "CORS_ALLOWED_HEADERS", usage: "headers allowed in cross-origin requests (comma separated)"},
+	{name: "CORS_MAX_AGE", usage: "seconds browsers can cache preflight responses for"},
+	{name: "DATABASE_HOST", usage: "database host"},
+	{name: "DATABASE_PORT", usage: "database port"},
+	{name: "DATABASE_USERNAME", usage: "database username"},
+	{name: "DATABASE_PASSWORD", usage: "database password", secret: true},
+	{name: "DATABASE_DBNAME", usage: "database name"},
+	{name: "SECRETS_PROVIDER", usage: "provider of the secrets referred to as 'secret://NAME': none or file"},
+	{name: "SECRETS_FILE", usage: "encrypted secrets file, for the file provider"},
+	{name: "SECRETS_KEY_FILE", usage: "file holding the base64 encoded key of the secrets file"},
+	{name: "SECRETS_REFRESH_INTERVAL", usage: "interval the database credentials are re-read at (0 disables)"},
+}
+
+// secretFileSuffix is the suffix of the settings giving the file a secret setting is read from.
+const secretFileSuffix = "_FILE"
+
+// secretReferencePrefix prefixes the values referring to a secret of the secret provider, by name.
+const secretReferencePrefix = "secret://"
+
+// configFileSetting is the setting giving the configuration file, which can't be set in the file itself.
+const configFileSetting = "CONFIG_FILE"
+
+// configSources holds the settings given in the configuration file and as command-line flags, by name.
+type configSources struct {
+	file  map[string]string
+	flags map[string]string
+}
+
+// ConfigErrors holds all the problems found in a configuration.
+type ConfigErrors []error
+
+// Error implements the error interface.
+func (errs ConfigErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// addf adds an error formatted like fmt.Errorf.
+func (errs *ConfigErrors) addf(format string, args ...interface{}) {
+	*errs = append(*errs, fmt.Errorf(format, args...))
+}
+
+// RegisterFlags registers a command-line flag for each setting (only for the '_FILE' variant of secret settings),
+// and --config for the configuration file.
+// Flags set on the command line take precedence over the environment when the configuration is loaded.
+func (config *Configuration) RegisterFlags(flags *flag.FlagSet) {
+	if config.sources.flags == nil {
+		config.sources.flags = make(map[string]string)
+	}
+
+	flags.Var(settingFlag{config: config, name: configFileSetting}, "config",
+		fmt.Sprintf("configuration file, YAML (.yaml, .yml) or TOML (.toml) (env %s_%s)", AppPrefix, configFileSetting))
+	for _, s := range settings {
+		usage := fmt.Sprintf("%s (env %s_%s)", s.usage, AppPrefix, s.name)
+		if s.structured {
+			usage = fmt.Sprintf("%s, as JSON (env %s_%s)", s.usage, AppPrefix, s.name)
+		}
+		if s.secret {
+			flags.Var(settingFlag{config: config, name: s.name + secretFileSuffix}, flagName(s.name+secretFileSuffix),
+				fmt.Sprintf("file to read the %s from (env %s_%s%s)", s.usage, AppPrefix, s.name, secretFileSuffix))
+			continue
+		}
+		flags.Var(settingFlag{config: config, name: s.name}, flagName(s.name), usage)
+	}
+}
+
+// flagName returns the name of the command-line flag of a setting.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// settingFlag is the command-line flag of a setting, which records its value when set.
+type settingFlag struct {
+	config *Configuration
+	name   string
+}
+
+// String implements flag.Value.
+func (f settingFlag) String() string {
+	if f.config == nil {
+		return ""
+	}
+	return f.config.sources.flags[f.name]
+}
+
+// Set implements flag.Value.
+func (f settingFlag) Set(value string) error {
+	f.config.sources.flags[f.name] = value
+	return nil
+}
+
+// resolve returns the value of a setting, from the command-line flags, the environment or the configuration
+// file, in that order of precedence. Secret settings can be given in any of those as the file to read them from.
+// Values referring to a secret ('secret://NAME') are replaced with the secret, from the secret provider.
+func (config *Configuration) resolve(name string) (string, bool, error) {
+	s, _ := lookupSetting(name)
+
+	value, ok, err := config.lookup(name, s.secret)
+	if err != nil || !ok {
+		return "", ok, err
+	}
+
+	if !strings.HasPrefix(value, secretReferencePrefix) {
+		return value, true, nil
+	}
+	secretName := strings.TrimPrefix(value, secretReferencePrefix)
+	if config.SecretProvider == nil {
+		return "", true, fmt.Errorf("secret <%s> referred to without a secret provider", secretName)
+	}
+	value, err = config.SecretProvider.GetSecret(secretName)
+	if err != nil {
+		return "", true, fmt.Errorf("secret <%s>: %s", secretName, err)
+	}
+	return value, true, nil
+}
+
+// lookup returns the value of a setting given in the command-line flags, the environment or the configuration
+// file, in that order of precedence. Secret settings are read from the file given in their '_FILE' variant
+// (e.g. a Docker or Kubernetes secret) if they're not given directly in the same source.
+func (config *Configuration) lookup(name string, secret bool) (string, bool, error) {
+	sources := []func(name string) (string, bool){
+		func(name string) (string, bool) {
+			value, ok := config.sources.flags[name]
+			return value, ok
+		},
+		func(name string) (string, bool) {
+			return os.LookupEnv(AppPrefix + "_" + name)
+		},
+		func(name string) (string, bool) {
+			value, ok := config.sources.file[name]
+			return value, ok
+		},
+	}
+
+	for _, source := range sources {
+		if value, ok := source(name); ok {
+			return value, true, nil
+		}
+		if !secret {
+			continue
+		}
+		if path, ok := source(name + secretFileSuffix); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", true, err
+			}
+			// Files usually end with a newline, which isn't part of the secret
+			return strings.TrimRight(string(data), "\r\n"), true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// configFile returns the path of the configuration file, if any.
+func (config *Configuration) configFile() (string, bool) {
+	if path, ok := config.sources.flags[configFileSetting]; ok {
+		return path, path != ""
+	}
+	path, ok := os.LookupEnv(AppPrefix + "_" + configFileSetting)
+	return path, ok && path != ""
+}
+
+// readFile reads the settings in a YAML or TOML configuration file, by extension.
+func (config *Configuration) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("unsupported format <%s>, must be .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s: %s", path, err)
+	}
+
+	config.sources.file = make(map[string]string)
+	var errs []string
+	flattenSettings("", values, config.sources.file, &errs)
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("unknown or invalid keys in %s: %s", path, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
+
+// flattenSettings adds the settings in a (decoded) configuration file section to values, by name, and the
+// keys (as dotted paths) that aren't settings or whose value is invalid to errs.
+func flattenSettings(path string, section map[string]interface{}, values map[string]string, errs *[]string) {
+	for key, value := range section {
+		keyPath := path + key
+		name := strings.ToUpper(strings.ReplaceAll(keyPath, ".", "_"))
+		s, ok := lookupSetting(name)
+
+		switch v := value.(type) {
+		case nil:
+			// Empty sections and keys are left out
+			continue
+		case map[string]interface{}:
+			if !ok || !s.structured {
+				flattenSettings(keyPath+".", v, values, errs)
+				continue
+			}
+		}
+
+		if !ok {
+			*errs = append(*errs, keyPath)
+			continue
+		}
+
+		text, err := settingValue(s, value)
+		if err != nil {
+			*errs = append(*errs, keyPath)
+			continue
+		}
+		values[name] = text
+	}
+}
+
+// lookupSetting returns the setting with the given name, which can be the '_FILE' variant of a secret setting.
+func lookupSetting(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.name == name {
+			return s, true
+		}
+	}
+
+	if base := strings.TrimSuffix(name, secretFileSuffix); base != name {
+		if s, ok := lookupSetting(base); ok && s.secret {
+			return setting{name: name}, true
+		}
+	}
+	return setting{}, false
+}
+
+// settingValue returns the value of a setting in a configuration file the way it would be given in an
+// environment variable: structured settings as JSON, lists comma separated and everything else as text.
+func settingValue(s setting, value interface{}) (string, error) {
+	if s.structured {
+		data, err := json.Marshal(value)
+		return string(data), err
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, fmt.Sprint(item))
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("unexpected section")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// DatabaseCredentials reads the database username and password again, from the files and secret provider they're
+// given by (e.g. to pick up rotated credentials). The configuration file isn't read again.
+func (config *Configuration) DatabaseCredentials() (username string, password string, err error) {
+	username, _, err = config.resolve("DATABASE_USERNAME")
+	if err != nil {
+		return "", "", fmt.Errorf("configuration error: [database username] %s", err)
+	}
+
+	password, _, err = config.resolve("DATABASE_PASSWORD")
+	if err != nil {
+		return "", "", fmt.Errorf("configuration error: [database password] %s", err)
+	}
+	return username, password, nil
+}
+
+// Redacted returns a copy of the configuration with its secrets redacted, e.g. to be printed.
+func (config Configuration) Redacted() Configuration {
+	redact := func(secret *string) {
+		if *secret != "" {
+			*secret = "REDACTED"
+		}
+	}
+
+	redact(&config.Database.Password)
+	redact(&config.Auth.AdminKey)
+	redact(&config.Auth.JWT.HMACSecret)
+	return config
+}
+
+// Print writes the configuration to w as YAML, which can be used as a configuration file.
+func (config Configuration) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
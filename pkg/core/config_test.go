@@ -0,0 +1,235 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+webserver:
+  host: 0.0.0.0
+  port: 8081
+  drain_delay: 10s
+  trusted_proxies: [10.0.0.1, 192.168.0.0/16]
+options:
+  log_level: debug
+database:
+  host: db
+  port: 3307
+  username: news
+  password: secret
+  dbname: news
+ingestion:
+  feeds:
+    - url: https://example.com/rss
+      provider: example
+      category: tech
+cors:
+  allowed_origins: [https://app.example.com, https://admin.example.com]
+ratelimit:
+  read:
+    rate: 2.5
+`)
+
+	t.Setenv(core.AppPrefix+"_WEBSERVER_PORT", "8082")
+	t.Setenv(core.AppPrefix+"_DATABASE_PORT", "3308")
+
+	config := core.NewConfig()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.RegisterFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--config", path, "--webserver-port", "8083"}))
+	require.NoError(t, config.LoadConfig())
+
+	// Flags override environment variables, which override the file, which overrides the defaults
+	assert.Equal(t, 8083, config.Webserver.Port)
+	assert.Equal(t, 3308, config.Database.Port)
+	assert.Equal(t, "0.0.0.0", config.Webserver.Host)
+	assert.Equal(t, 10*time.Second, config.Webserver.DrainDelay)
+	assert.Equal(t, []string{"10.0.0.1", "192.168.0.0/16"}, config.Webserver.TrustedProxies)
+	assert.Equal(t, log.DEBUG, config.Options.LogLevel)
+	assert.Equal(t, "secret", config.Database.Password)
+	assert.Equal(t, []core.FeedConfiguration{{URL: "https://example.com/rss", Provider: "example", Category: "tech"}},
+		config.Ingestion.Feeds)
+	assert.Equal(t, []string{"https://app.example.com", "https://admin.example.com"}, config.CORS.AllowedOrigins)
+	assert.Equal(t, 2.5, config.RateLimit.Read.Rate)
+	assert.Equal(t, 20, config.RateLimit.Read.Burst)
+	assert.Equal(t, 15*time.Minute, config.Ingestion.PollInterval)
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", `
+[webserver]
+port = 8081
+
+[webserver.cache_control]
+"/api/v1/articles" = "max-age=60"
+
+[database]
+host = "db"
+username = "news"
+password = "secret"
+dbname = "news"
+
+[[ingestion.feeds]]
+url = "https://example.com/rss"
+provider = "example"
+category = "tech"
+`)
+	t.Setenv(core.AppPrefix+"_CONFIG_FILE", path)
+
+	config := core.NewConfig()
+	require.NoError(t, config.LoadConfig())
+	assert.Equal(t, 8081, config.Webserver.Port)
+	assert.Equal(t, map[string]string{"/api/v1/articles": "max-age=60"}, config.Webserver.CacheControl)
+	assert.Len(t, config.Ingestion.Feeds, 1)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", `
+webserver:
+  port: 0
+  trusted_proxies: [10.0.0.1, lb.internal]
+  unknown: true
+database:
+  host: db
+tracing:
+  exporter: jaeger
+`)
+	t.Setenv(core.AppPrefix+"_RATELIMIT_READ_BURST", "none")
+	t.Setenv(core.AppPrefix+"_AUTH_API_KEYS", "true")
+
+	config := core.NewConfig()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.RegisterFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--config", path}))
+
+	err := config.LoadConfig()
+	require.Error(t, err)
+	errs, ok := err.(core.ConfigErrors)
+	require.True(t, ok)
+
+	// All the problems are reported at once
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"configuration error: [config file] unknown or invalid keys in " + path + ": webserver.unknown",
+		"configuration error: [webserver port] input not allowed <0>",
+		"configuration error: [webserver trustedproxies] input not allowed <lb.internal>",
+		"configuration error: [auth apikeys] API keys can't be managed without an admin key or JWT authentication",
+		"configuration error: [ratelimit read burst] input not allowed <none>",
+		"configuration error: [tracing exporter] input not allowed <jaeger>",
+		"configuration error: [database username] mandatory config parameter missing",
+		"configuration error: [database password] mandatory config parameter missing",
+		"configuration error: [database dbname] mandatory config parameter missing",
+	}, messages)
+}
+
+type fakeSecretProvider map[string]string
+
+func (p fakeSecretProvider) GetSecret(name string) (string, error) {
+	secret, ok := p[name]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+func TestLoadConfigSecrets(t *testing.T) {
+	passwordPath := writeConfigFile(t, "password", "password 1\n")
+	path := writeConfigFile(t, "config.yaml", `
+database:
+  host: db
+  username: secret://db-username
+  password_file: `+passwordPath+`
+  dbname: news
+`)
+	t.Setenv(core.AppPrefix+"_CONFIG_FILE", path)
+	t.Setenv(core.AppPrefix+"_AUTH_ADMIN_KEY", "secret://unknown")
+
+	provider := fakeSecretProvider{"db-username": "user 1"}
+	config := core.NewConfig()
+	config.SecretProvider = provider
+	err := config.LoadConfig()
+	require.EqualError(t, err, "configuration error: [auth admin key] secret <unknown>: secret not found")
+	assert.Equal(t, "user 1", config.Database.Username)
+	assert.Equal(t, "password 1", config.Database.Password)
+
+	// Rotated credentials are read again
+	provider["db-username"] = "user 2"
+	require.NoError(t, os.WriteFile(passwordPath, []byte("password 2"), 0o600))
+	username, password, err := config.DatabaseCredentials()
+	require.NoError(t, err)
+	assert.Equal(t, "user 2", username)
+	assert.Equal(t, "password 2", password)
+
+	// A secret given directly takes precedence over its file in the same source, not over other sources
+	t.Setenv(core.AppPrefix+"_AUTH_ADMIN_KEY", "0123456789abcdef")
+	t.Setenv(core.AppPrefix+"_DATABASE_PASSWORD", "password 3")
+	config = core.NewConfig()
+	config.SecretProvider = provider
+	require.NoError(t, config.LoadConfig())
+	assert.Equal(t, "password 3", config.Database.Password)
+
+	// Secrets can't be given as command-line flags, only their files can
+	config = core.NewConfig()
+	config.SecretProvider = provider
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	config.RegisterFlags(flags)
+	assert.Error(t, flags.Parse([]string{"--database-password", "password 4"}))
+	require.NoError(t, flags.Parse([]string{"--database-password-file", passwordPath}))
+	require.NoError(t, config.LoadConfig())
+	assert.Equal(t, "password 2", config.Database.Password)
+}
+
+func TestPrintConfig(t *testing.T) {
+	t.Setenv(core.AppPrefix+"_DATABASE_HOST", "db")
+	t.Setenv(core.AppPrefix+"_DATABASE_USERNAME", "news")
+	t.Setenv(core.AppPrefix+"_DATABASE_PASSWORD", "hunter2")
+	t.Setenv(core.AppPrefix+"_DATABASE_DBNAME", "news")
+	t.Setenv(core.AppPrefix+"_AUTH_ADMIN_KEY", "0123456789abcdef")
+
+	config := core.NewConfig()
+	require.NoError(t, config.LoadConfig())
+
+	var buf bytes.Buffer
+	require.NoError(t, config.Redacted().Print(&buf))
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.NotContains(t, buf.String(), "0123456789abcdef")
+	assert.Contains(t, buf.String(), "password: REDACTED")
+	assert.Contains(t, buf.String(), "log_level: info")
+	assert.Contains(t, buf.String(), "poll_interval: 15m0s")
+	// The original isn't redacted
+	assert.Equal(t, "hunter2", config.Database.Password)
+
+	// The printed configuration can be read back
+	path := writeConfigFile(t, "config.yaml", buf.String())
+	t.Setenv(core.AppPrefix+"_CONFIG_FILE", path)
+	reloaded := core.NewConfig()
+	require.NoError(t, reloaded.LoadConfig())
+	assert.Equal(t, config.Webserver, reloaded.Webserver)
+	assert.Equal(t, config.Options, reloaded.Options)
+	assert.Equal(t, config.Ingestion.PollInterval, reloaded.Ingestion.PollInterval)
+	assert.Equal(t, config.RateLimit, reloaded.RateLimit)
+	assert.Equal(t, config.CORS, reloaded.CORS)
+}
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ArticleInput represents an article given to the service (by API clients, feeds or imports), along with its
+// validation rules.
+// The rules are declared in 'binding' tags, so that the API validates request bodies with them as well.
+type ArticleInput struct {
+	GUID          string    `json:"guid" binding:"required"`
+	Title         string    `json:"title" binding:"required"`
+	Description   string    `json:"description" binding:"required"`
+	Link          string    `json:"link" binding:"required"`
+	PublishedTime time.Time `json:"published_date" binding:"required"`
+	Provider      string    `json:"provider" binding:"required"`
+	Category      string    `json:"category" binding:"required"`
+}
+
+// inputValidator validates inputs with the rules in their 'binding' tags, naming fields after their JSON property.
+var inputValidator = func() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return v
+}()
+
+// Validate validates the article with its validation rules.
+func (a ArticleInput) Validate() error {
+	return inputValidator.Struct(a)
+}
+
+// Article converts the input into an Article with the published time in UTC.
+func (a ArticleInput) Article() Article {
+	return Article{
+		GUID:          a.GUID,
+		Title:         a.Title,
+		Description:   a.Description,
+		Link:          a.Link,
+		PublishedTime: a.PublishedTime.UTC(),
+		Provider:      a.Provider,
+		Category:      a.Category,
+	}
+}
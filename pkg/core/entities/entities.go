@@ -2,6 +2,7 @@ package entities
 
 import "time"
 
+// Article is a news article, identified by its GUID.
 type Article struct {
 	GUID          string    `json:"guid"`
 	Title         string    `json:"title"`
@@ -12,4 +13,128 @@ type Article struct {
 	Category      string    `json:"category"`
 }
 
+// Articles is a list of articles.
 type Articles []Article
+
+// ArticleCount is the number of articles published in a time bucket, by provider and/or category if grouped.
+type ArticleCount struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Provider    string    `json:"provider,omitempty"`
+	Category    string    `json:"category,omitempty"`
+	Count       int64     `json:"count"`
+}
+
+// ArticleCounts is a list of article counts.
+type ArticleCounts []ArticleCount
+
+// ArticlesVersion tells apart the states of the articles matching some criteria. Articles are never updated nor
+// deleted, so their count changes whenever the ones matching do.
+type ArticlesVersion struct {
+	Count         int64
+	LastPublished time.Time
+}
+
+// Feed is an RSS or Atom feed articles are ingested from, along with the outcome of its last fetch.
+type Feed struct {
+	ID                  uint64     `json:"id"`
+	URL                 string     `json:"url"`
+	Provider            string     `json:"provider"`
+	Category            string     `json:"category"`
+	PollIntervalSeconds int        `json:"poll_interval_seconds"`
+	Enabled             bool       `json:"enabled"`
+	LastStatus          string     `json:"last_status"`
+	LastError           string     `json:"last_error"`
+	LastFetchedAt       *time.Time `json:"last_fetched_at"`
+	LastSuccessAt       *time.Time `json:"last_success_at"`
+	ETag                string     `json:"etag"`
+	LastModified        string     `json:"last_modified"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	NextFetchAt         *time.Time `json:"next_fetch_at"`
+}
+
+// Feeds is a list of feeds.
+type Feeds []Feed
+
+// FeedFetch is the outcome of fetching a feed, and when to fetch it next.
+type FeedFetch struct {
+	FetchedAt           time.Time
+	Error               string
+	ETag                string
+	LastModified        string
+	ConsecutiveFailures int
+	NextFetchAt         *time.Time
+	Disable             bool
+}
+
+// Statuses of the last fetch of a feed.
+const (
+	FeedStatusOK    = "ok"
+	FeedStatusError = "error"
+)
+
+// Webhook is a URL the articles added are delivered to, optionally filtered by provider and category.
+type Webhook struct {
+	ID        uint64    `json:"id"`
+	URL       string    `json:"url"`
+	Provider  string    `json:"provider,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhooks is a list of webhooks.
+type Webhooks []Webhook
+
+// WebhookDelivery is the delivery of an event to a webhook, and the outcome of its attempts.
+type WebhookDelivery struct {
+	ID             uint64     `json:"id"`
+	WebhookID      uint64     `json:"webhook_id"`
+	ArticleGUID    string     `json:"article_guid"`
+	Payload        string     `json:"-"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+}
+
+// WebhookDeliveries is a list of webhook deliveries.
+type WebhookDeliveries []WebhookDelivery
+
+// Statuses of a webhook delivery.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// APIKey is a key authenticating clients of the API. Only the hash of the key is kept.
+type APIKey struct {
+	ID        uint64    `json:"id"`
+	Label     string    `json:"label"`
+	Prefix    string    `json:"prefix"`
+	Hash      string    `json:"-"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// APIKeys is a list of API keys.
+type APIKeys []APIKey
+
+// Event is an article lifecycle event, recorded in the outbox of the repository.
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Article    Article   `json:"article"`
+}
+
+// Events is a list of events.
+type Events []Event
+
+// Types of events.
+const (
+	EventArticleCreated = "article.created"
+)
@@ -11,10 +11,72 @@ import (
 type Repository interface {
 	HealthCheck() error
 	GetArticles(provider string, category string, sorting string, limit int, after *time.Time) (articles entities.Articles, err error)
+	GetArticlesVersion(provider string, category string, sorting string, after *time.Time) (version entities.ArticlesVersion, err error)
 	AddArticle(article entities.Article) (err error)
+	AddArticles(articles entities.Articles) (duplicates []string, err error)
+	StreamArticles(provider string, category string, after *time.Time, afterGUID string, fn func(article entities.Article) error) (err error)
+	GetArticleStats(interval string, byProvider bool, byCategory bool, from time.Time, to time.Time) (counts entities.ArticleCounts, err error)
+
+	GetFeeds() (feeds entities.Feeds, err error)
+	GetFeed(id uint64) (feed entities.Feed, err error)
+	AddFeed(feed entities.Feed) (newFeed entities.Feed, err error)
+	UpdateFeed(feed entities.Feed) (err error)
+	UpdateFeedStatus(id uint64, fetch entities.FeedFetch) (err error)
+	DeleteFeed(id uint64) (err error)
+
+	GetWebhooks() (webhooks entities.Webhooks, err error)
+	GetWebhook(id uint64) (webhook entities.Webhook, err error)
+	AddWebhook(webhook entities.Webhook) (newWebhook entities.Webhook, err error)
+	DeleteWebhook(id uint64) (err error)
+	AddWebhookDeliveries(deliveries entities.WebhookDeliveries) (err error)
+	ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) (deliveries entities.WebhookDeliveries,
+		err error)
+	GetWebhookDeliveries(webhookID uint64, limit int) (deliveries entities.WebhookDeliveries, err error)
+	UpdateWebhookDelivery(delivery entities.WebhookDelivery) (err error)
+
+	GetAPIKeys() (keys entities.APIKeys, err error)
+	GetAPIKeyByHash(hash string) (key entities.APIKey, err error)
+	AddAPIKey(key entities.APIKey) (newKey entities.APIKey, err error)
+	SetAPIKeyEnabled(id uint64, enabled bool) (err error)
+
+	ClaimPendingEvents(claimant string, now time.Time, lease time.Duration, limit int) (events entities.Events,
+		err error)
+	GetEventsAfter(afterID uint64, limit int) (events entities.Events, err error)
+	GetLastEventID() (id uint64, err error)
+	MarkEventsPublished(ids []uint64, publishedAt time.Time) (err error)
+	DeletePublishedEvents(before time.Time) (deleted int64, err error)
+}
+
+// ContextRepository represents a repository whose calls can be bound to a context, e.g. to be traced as part
+// of the request they're made for.
+type ContextRepository interface {
+	WithContext(ctx context.Context) Repository
+}
+
+// RepositoryWithContext returns repo with its calls bound to ctx, if it's a ContextRepository, or repo otherwise.
+func RepositoryWithContext(ctx context.Context, repo Repository) Repository {
+	if repo, ok := repo.(ContextRepository); ok {
+		return repo.WithContext(ctx)
+	}
+	return repo
+}
+
+// EventPublisher represents a destination for article lifecycle events, like a message broker.
+type EventPublisher interface {
+	Publish(event entities.Event) error
+}
+
+// SecretProvider represents a store of secrets, like a secret manager, configuration settings can refer to.
+type SecretProvider interface {
+	GetSecret(name string) (string, error)
 }
 
 // ShutDowner represents anything that can be shutdown like an HTTP server.
 type ShutDowner interface {
 	ShutDown(ctx context.Context) error
 }
+
+// Drainer represents anything that stops taking new work ahead of a shutdown, like a readiness probe.
+type Drainer interface {
+	Drain()
+}
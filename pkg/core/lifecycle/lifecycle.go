@@ -14,12 +14,22 @@ import (
 
 // TerminateHandler terminates the application.
 // This function waits on a SIGINT or SIGTERM signal and shuts down the HTTP server gracefully.
-func TerminateHandler(logger log.Logger, server core.ShutDowner) {
+// The drainer (if not nil) is drained first, and the server keeps serving for drainDelay, so that load
+// balancers notice the application is going away before it stops taking requests.
+func TerminateHandler(logger log.Logger, server core.ShutDowner, drainer core.Drainer, drainDelay time.Duration) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("shutting down application ...")
 
+	if drainer != nil {
+		drainer.Drain()
+	}
+	if drainDelay > 0 {
+		logger.Info(fmt.Sprintf("draining for %s ...", drainDelay))
+		time.Sleep(drainDelay)
+	}
+
 	// We will wait 5 seconds for the server to shutdown gracefully
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
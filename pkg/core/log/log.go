@@ -1,6 +1,8 @@
 // Package log provides an interface and a few helper functions/constants.
 package log
 
+import "time"
+
 type FieldsMap map[string]interface{}
 
 type FieldFunc func(FieldsMap)
@@ -13,6 +15,16 @@ type Logger interface {
 	Error(msg string, fields ...FieldFunc)
 }
 
+// LevelController is a logger whose level can be changed at runtime, either for good or for a while.
+type LevelController interface {
+	Level() Level
+	SetLevel(level Level)
+	// SetLevelFor sets the level for the given duration, after which it reverts, and returns when it does.
+	SetLevelFor(level Level, duration time.Duration) time.Time
+	// PendingRevert returns the level the logger reverts to and when, if its level was changed for a while.
+	PendingRevert() (level Level, at time.Time, ok bool)
+}
+
 // LogLevel defines the log level constants.
 type Level uint
 
@@ -23,6 +35,27 @@ const (
 	ERROR Level = 40
 )
 
+// String returns the name of the level, as parsed by core.ParseLogLevel.
+func (l Level) String() string {
+	switch l {
+	case DEBUG:
+		return "debug"
+	case INFO:
+		return "info"
+	case WARN:
+		return "warning"
+	case ERROR:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler, so that levels are written by name.
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
 func Field(key string, value interface{}) FieldFunc {
 	return func(newFields FieldsMap) {
 		newFields[key] = value
@@ -0,0 +1,36 @@
+package log
+
+// withFields is a logger adding a set of fields to every message.
+type withFields struct {
+	logger Logger
+	fields []FieldFunc
+}
+
+// WithFields returns a logger adding the given fields to every message logged with it, e.g. to tie all
+// messages about a request together.
+func WithFields(logger Logger, fields ...FieldFunc) Logger {
+	// The fields are copied, as they're shared by every message
+	return withFields{logger: logger, fields: append([]FieldFunc(nil), fields...)}
+}
+
+func (l withFields) Debug(msg string, fields ...FieldFunc) { l.logger.Debug(msg, l.merge(fields)...) }
+func (l withFields) Info(msg string, fields ...FieldFunc)  { l.logger.Info(msg, l.merge(fields)...) }
+func (l withFields) Warn(msg string, fields ...FieldFunc)  { l.logger.Warn(msg, l.merge(fields)...) }
+func (l withFields) Error(msg string, fields ...FieldFunc) { l.logger.Error(msg, l.merge(fields)...) }
+
+// merge returns the logger's fields followed by the message's, which take precedence. Nil fields are skipped.
+func (l withFields) merge(fields []FieldFunc) []FieldFunc {
+	// Appending to l.fields would have concurrent messages write to the same backing array
+	merged := make([]FieldFunc, 0, len(l.fields)+len(fields))
+	for _, f := range l.fields {
+		if f != nil {
+			merged = append(merged, f)
+		}
+	}
+	for _, f := range fields {
+		if f != nil {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
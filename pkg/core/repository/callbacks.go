@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"runtime"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// callbackRegisterer registers a gorm callback at the position it was created for.
+type callbackRegisterer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// registerCallbacks registers gorm callbacks run before and after every SQL statement, named after name and the
+// operation (create, query, update, delete, row or raw) of the statement.
+func registerCallbacks(db *gorm.DB, name string, before func(operation string) func(*gorm.DB),
+	after func(operation string) func(*gorm.DB)) error {
+
+	callback := db.Callback()
+	hooks := []struct {
+		operation string
+		before    callbackRegisterer
+		after     callbackRegisterer
+	}{
+		{"create", callback.Create().Before("gorm:create"), callback.Create().After("gorm:create")},
+		{"query", callback.Query().Before("gorm:query"), callback.Query().After("gorm:query")},
+		{"update", callback.Update().Before("gorm:update"), callback.Update().After("gorm:update")},
+		{"delete", callback.Delete().Before("gorm:delete"), callback.Delete().After("gorm:delete")},
+		{"row", callback.Row().Before("gorm:row"), callback.Row().After("gorm:row")},
+		{"raw", callback.Raw().Before("gorm:raw"), callback.Raw().After("gorm:raw")},
+	}
+
+	for _, hook := range hooks {
+		if err := hook.before.Register(name+":before_"+hook.operation, before(hook.operation)); err != nil {
+			return err
+		}
+		if err := hook.after.Register(name+":after_"+hook.operation, after(hook.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startKey is the gorm instance key under which the start time of the statement being run is stored.
+const startKey = "metrics:start"
+
+// StatementObserver is called after every SQL statement with the repository method it was run for (see
+// repositoryMethod), its operation (create, query, update, delete, row or raw), table (empty for raw statements),
+// duration and error, translated into the service errors.
+type StatementObserver func(method string, operation string, table string, duration time.Duration, err error)
+
+// ObserveStatements registers observer to be called after every SQL statement, e.g. to record metrics.
+func (db *Database) ObserveStatements(observer StatementObserver) error {
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			tx.InstanceSet(startKey, time.Now())
+		}
+	}
+
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(startKey)
+			if !ok {
+				return
+			}
+
+			var err error
+			if tx.Error != nil {
+				err = translateError(tx.Error)
+			}
+			observer(repositoryMethod(), operation, tx.Statement.Table, time.Since(value.(time.Time)), err)
+		}
+	}
+
+	return registerCallbacks(db.conn, "metrics", before, after)
+}
+
+// serviceMethodPrefix prefixes the names of the DatabaseService methods in stack traces.
+const serviceMethodPrefix = "repository.(*DatabaseService)."
+
+// repositoryMethod returns the name of the DatabaseService method (e.g. 'GetArticles') the statement being run
+// was made for, found in the stack of the calling goroutine, or an empty string if it wasn't made for any (e.g.
+// migrations).
+func repositoryMethod() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if i := strings.Index(frame.Function, serviceMethodPrefix); i >= 0 {
+			method := frame.Function[i+len(serviceMethodPrefix):]
+			// Closures are named after the method they're in, e.g. 'StreamArticles.func1'
+			if j := strings.IndexByte(method, '.'); j >= 0 {
+				method = method[:j]
+			}
+			return method
+		}
+		if !more {
+			return ""
+		}
+	}
+}
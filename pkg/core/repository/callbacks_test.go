@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestObserveStatements(t *testing.T) {
+	server := &fakeServer{password: "password"}
+	connector := newCredentialsConnector("db", 3306, "user", "password", "news")
+	connector.connect = server.connect
+
+	conn, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sql.OpenDB(connector), SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	db := &Database{conn: conn, connector: connector}
+
+	type statement struct {
+		method    string
+		operation string
+		table     string
+		err       error
+	}
+	var statements []statement
+	require.NoError(t, db.ObserveStatements(func(method string, operation string, table string,
+		duration time.Duration, err error) {
+		statements = append(statements, statement{method: method, operation: operation, table: table, err: err})
+	}))
+
+	// The fake connections can't run statements, so they all fail
+	dbs := &DatabaseService{Database: db}
+	_, err = dbs.GetFeeds()
+	assert.Error(t, err)
+	_, err = db.FindAllFeedRecords()
+	assert.Error(t, err)
+
+	require.Len(t, statements, 2)
+	assert.Equal(t, statement{method: "GetFeeds", operation: "query", table: "feeds", err: statements[0].err},
+		statements[0])
+	assert.IsType(t, &DBServiceError{}, statements[0].err)
+	// Statements not made for a repository method have no method
+	assert.Equal(t, "", statements[1].method)
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// credentialsConnector opens connections to MySQL with the current credentials, which can be changed while the
+// connection pool is in use (e.g. when they're rotated).
+type credentialsConnector struct {
+	mu     sync.Mutex
+	config *mysql.Config
+	// connect opens a connection with a configuration.
+	connect func(ctx context.Context, config *mysql.Config) (driver.Conn, error)
+}
+
+// newCredentialsConnector returns a new credentialsConnector.
+func newCredentialsConnector(host string, port int, username string, password string, dbname string) *credentialsConnector {
+	config := mysql.NewConfig()
+	config.User = username
+	config.Passwd = password
+	config.Net = "tcp"
+	config.Addr = fmt.Sprintf("%s:%d", host, port)
+	config.DBName = dbname
+	config.Params = map[string]string{"charset": "utf8mb4"}
+	config.ParseTime = true
+	// Dates are stored and read in UTC, so that the buckets of the article stats, computed by the database from
+	// the stored dates, are in UTC like the API says
+	config.Loc = time.UTC
+
+	return &credentialsConnector{config: config, connect: connectMySQL}
+}
+
+// connectMySQL opens a connection to MySQL with a configuration.
+func connectMySQL(ctx context.Context, config *mysql.Config) (driver.Conn, error) {
+	connector, err := mysql.NewConnector(config)
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+// Connect implements driver.Connector.
+func (c *credentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	config := c.config.Clone()
+	c.mu.Unlock()
+
+	return c.connect(ctx, config)
+}
+
+// Driver implements driver.Connector.
+func (c *credentialsConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+// credentialsChanged reports whether credentials differ from the current ones.
+func (c *credentialsConnector) credentialsChanged(username string, password string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.config.User != username || c.config.Passwd != password
+}
+
+// checkCredentials opens (and closes) a connection with the given credentials, to check that they're accepted,
+// without changing the current ones.
+func (c *credentialsConnector) checkCredentials(ctx context.Context, username string, password string) error {
+	c.mu.Lock()
+	config := c.config.Clone()
+	c.mu.Unlock()
+
+	config.User = username
+	config.Passwd = password
+	conn, err := c.connect(ctx, config)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// setCredentials sets the credentials of the connections opened from now on, and reports whether they changed.
+func (c *credentialsConnector) setCredentials(username string, password string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config.User == username && c.config.Passwd == password {
+		return false
+	}
+	c.config.User = username
+	c.config.Passwd = password
+	return true
+}
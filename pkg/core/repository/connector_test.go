@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeConn is a connection that can't run anything, but can be opened and closed.
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (fakeConn) Close() error                              { return nil }
+func (fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+// fakeServer accepts connections with its password, recording the credentials of every connection attempt.
+type fakeServer struct {
+	mu       sync.Mutex
+	password string
+	attempts []string
+}
+
+func (s *fakeServer) connect(ctx context.Context, config *mysql.Config) (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts = append(s.attempts, config.User+":"+config.Passwd)
+	if config.Passwd != s.password {
+		return nil, errors.New("access denied")
+	}
+	return fakeConn{}, nil
+}
+
+func (s *fakeServer) lastAttempt() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[len(s.attempts)-1]
+}
+
+func TestCredentialsConnectorSetCredentials(t *testing.T) {
+	server := &fakeServer{password: "password 1"}
+	connector := newCredentialsConnector("db", 3306, "user 1", "password 1", "news")
+	connector.connect = server.connect
+
+	assert.False(t, connector.setCredentials("user 1", "password 1"))
+	assert.True(t, connector.setCredentials("user 1", "password 2"))
+	assert.True(t, connector.setCredentials("user 2", "password 2"))
+	assert.False(t, connector.credentialsChanged("user 2", "password 2"))
+
+	// Connections are opened with the credentials set
+	_, err := connector.Connect(context.Background())
+	assert.EqualError(t, err, "access denied")
+	assert.Equal(t, "user 2:password 2", server.lastAttempt())
+
+	// Checking credentials doesn't change the ones connections are opened with
+	require.NoError(t, connector.checkCredentials(context.Background(), "user 1", "password 1"))
+	assert.Equal(t, "user 1:password 1", server.lastAttempt())
+	assert.True(t, connector.credentialsChanged("user 1", "password 1"))
+	_, err = connector.Connect(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "user 2:password 2", server.lastAttempt())
+}
+
+func TestDatabaseSetCredentials(t *testing.T) {
+	server := &fakeServer{password: "password 1"}
+	connector := newCredentialsConnector("db", 3306, "user", "password 1", "news")
+	connector.connect = server.connect
+
+	sqlDB := sql.OpenDB(connector)
+	conn, err := gorm.Open(gormmysql.New(gormmysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	db := &Database{conn: conn, connector: connector}
+
+	// newConnection checks the database with a new connection, rather than an idle one.
+	newConnection := func() error {
+		sqlDB.SetMaxIdleConns(0)
+		sqlDB.SetMaxIdleConns(maxIdleConns)
+		return db.HealthCheck()
+	}
+
+	// Unchanged credentials aren't checked
+	attempts := len(server.attempts)
+	changed, err := db.SetCredentials("user", "password 1")
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Len(t, server.attempts, attempts)
+
+	// Credentials the database doesn't accept yet are kept aside: connections still use the current ones
+	changed, err = db.SetCredentials("user", "password 2")
+	assert.EqualError(t, err, "access denied")
+	assert.False(t, changed)
+	require.NoError(t, newConnection())
+	assert.Equal(t, "user:password 1", server.lastAttempt())
+
+	// They're tried again on every call, until they're accepted
+	changed, err = db.SetCredentials("user", "password 2")
+	assert.EqualError(t, err, "access denied")
+	assert.False(t, changed)
+	assert.Equal(t, "user:password 2", server.lastAttempt())
+
+	server.mu.Lock()
+	server.password = "password 2"
+	server.mu.Unlock()
+	changed, err = db.SetCredentials("user", "password 2")
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	// The idle connections were closed, and new ones use the new credentials
+	attempts = len(server.attempts)
+	require.NoError(t, db.HealthCheck())
+	assert.Len(t, server.attempts, attempts+1)
+	assert.Equal(t, "user:password 2", server.lastAttempt())
+}
@@ -1,24 +1,36 @@
 package repository
 
 import (
-	"fmt"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/stats"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
+// maxIdleConns is the number of idle connections kept in the pool (the default of database/sql).
+const maxIdleConns = 2
+
+// credentialsCheckTimeout is the time a connection has to open with new credentials before they're rejected.
+const credentialsCheckTimeout = 10 * time.Second
+
 // Database represents the database manager connecting to the database.
 type Database struct {
 	conn *gorm.DB
+	// connector opens the connections of the pool, with credentials that can be changed.
+	connector *credentialsConnector
 }
 
 // NewDatabase returns a new Database.
 func NewDatabase(host string, port int, username string, password string, dbname string) (*Database, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		username, password, host, port, dbname)
+	connector := newCredentialsConnector(host, port, username, password, dbname)
 
 	// TODO: Setup logger for gorm here
 	// I should implement GORM's logger interface on core.AppLogger and pass it to gorm config.
@@ -26,16 +38,31 @@ func NewDatabase(host string, port int, username string, password string, dbname
 
 	// dbconn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
 	// dbconn = dbconn.Debug()
-	dbconn, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	dbconn, err := gorm.Open(mysql.New(mysql.Config{Conn: sql.OpenDB(connector)}),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
 	if err != nil {
 		return nil, err
 	}
 
+	if err := registerTracing(dbconn); err != nil {
+		return nil, err
+	}
+
 	dbconn = dbconn.Session(&gorm.Session{})
-	db := Database{conn: dbconn}
+	db := Database{conn: dbconn, connector: connector}
 	return &db, nil
 }
 
+// WithContext returns a Database running its statements with ctx, which carries the span they're traced under.
+func (db *Database) WithContext(ctx context.Context) *Database {
+	return &Database{conn: db.conn.WithContext(ctx), connector: db.connector}
+}
+
+// Migrate creates or updates the tables added to the initial schema (articles, providers and categories).
+func (db *Database) Migrate() error {
+	return db.conn.AutoMigrate(&Feed{}, &Webhook{}, &WebhookDelivery{}, &APIKey{}, &OutboxEvent{})
+}
+
 // Close closes all database connections.
 func (db *Database) Close() error {
 	sqlDB, err := db.conn.DB()
@@ -46,6 +73,45 @@ func (db *Database) Close() error {
 	return sqlDB.Close()
 }
 
+// Stats returns the statistics of the connection pool.
+func (db *Database) Stats() (sql.DBStats, error) {
+	sqlDB, err := db.conn.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+
+	return sqlDB.Stats(), nil
+}
+
+// SetCredentials changes the credentials the connections to the database are opened with, once a connection
+// opened with them succeeded. Until then, the current credentials are kept (a rotation may not have reached the
+// database yet), so calling it again with the same credentials tries them again.
+// If they changed, the idle connections are closed, so that the pool reconnects with them.
+func (db *Database) SetCredentials(username string, password string) (changed bool, err error) {
+	if !db.connector.credentialsChanged(username, password) {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialsCheckTimeout)
+	defer cancel()
+	if err := db.connector.checkCredentials(ctx, username, password); err != nil {
+		return false, err
+	}
+
+	sqlDB, err := db.conn.DB()
+	if err != nil {
+		return false, err
+	}
+
+	db.connector.setCredentials(username, password)
+
+	// Connections in use are kept until they're closed (e.g. by the server), as they're still authenticated
+	sqlDB.SetMaxIdleConns(0)
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+
+	return true, nil
+}
+
 // HealthCheck checks whether the database is still around.
 func (db *Database) HealthCheck() error {
 	sqlDB, err := db.conn.DB()
@@ -74,13 +140,14 @@ func (db *Database) FindAllArticleRecords(provider string, category string, sort
 		chain = chain.Where("`Category`.`name` = ?", category)
 	}
 
+	// Articles published at the same time are sorted by GUID, so that listings don't change between queries
 	if sorting == "asc" {
-		chain = chain.Order("published_date asc")
+		chain = chain.Order("published_date asc, `articles`.`guid` asc")
 		if after != nil {
 			chain = chain.Where("published_date > ?", after)
 		}
 	} else {
-		chain = chain.Order("published_date desc")
+		chain = chain.Order("published_date desc, `articles`.`guid` desc")
 		if after != nil {
 			chain = chain.Where("published_date < ?", after)
 		}
@@ -92,32 +159,549 @@ func (db *Database) FindAllArticleRecords(provider string, category string, sort
 	return articleResults, result.Error
 }
 
-// InsertArticleRecord inserts a new article record in the database.
+// FindArticleRecordsVersion counts the article records FindAllArticleRecords would find with the same criteria
+// (limit aside) and finds the newest published date among them, which is cheaper than finding them.
+func (db *Database) FindArticleRecordsVersion(provider string, category string, sorting string, after *time.Time) (ArticlesVersionRow, error) {
+	var row ArticlesVersionRow
+	chain := db.conn.Model(&Article{}).
+		Select("COUNT(*) AS count, MAX(`articles`.`published_date`) AS last_published_date")
+
+	if provider != "" {
+		chain = chain.Joins("JOIN `providers` ON `providers`.`id` = `articles`.`provider_id`").
+			Where("`providers`.`name` = ?", provider)
+	}
+
+	if category != "" {
+		chain = chain.Joins("JOIN `categories` ON `categories`.`id` = `articles`.`category_id`").
+			Where("`categories`.`name` = ?", category)
+	}
+
+	if after != nil {
+		if sorting == "asc" {
+			chain = chain.Where("`articles`.`published_date` > ?", after)
+		} else {
+			chain = chain.Where("`articles`.`published_date` < ?", after)
+		}
+	}
+
+	result := chain.Scan(&row)
+	return row, result.Error
+}
+
+// IterateArticleRecords iterates over all the article records matching a certain criteria using a
+// server-side cursor, calling fn for each one of them.
+// Records are sorted by published date and GUID (ascending), which allows resuming the iteration from the
+// last record seen by passing its published date and GUID in after and afterGUID.
+// Iteration stops at the first error returned by fn.
+func (db *Database) IterateArticleRecords(provider string, category string, after *time.Time, afterGUID string, fn func(ArticleRow) error) error {
+	chain := db.conn.Model(&Article{}).
+		Select("`articles`.`guid`, `articles`.`title`, `articles`.`description`, `articles`.`link`, " +
+			"`articles`.`published_date`, `providers`.`name` AS provider_name, `categories`.`name` AS category_name").
+		Joins("JOIN `providers` ON `providers`.`id` = `articles`.`provider_id`").
+		Joins("JOIN `categories` ON `categories`.`id` = `articles`.`category_id`")
+
+	if provider != "" {
+		chain = chain.Where("`providers`.`name` = ?", provider)
+	}
+
+	if category != "" {
+		chain = chain.Where("`categories`.`name` = ?", category)
+	}
+
+	if after != nil {
+		if afterGUID != "" {
+			chain = chain.Where("`articles`.`published_date` > ? OR (`articles`.`published_date` = ? AND `articles`.`guid` > ?)",
+				after, after, afterGUID)
+		} else {
+			chain = chain.Where("`articles`.`published_date` > ?", after)
+		}
+	}
+
+	rows, err := chain.Order("`articles`.`published_date` asc, `articles`.`guid` asc").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row ArticleRow
+		if err := db.conn.ScanRows(rows, &row); err != nil {
+			return err
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CountArticleRecords counts the article records published in [from, to) grouped by time bucket and,
+// optionally, by provider and/or category.
+// Buckets are returned as strings in the 'YYYY-MM-DD hh:mm:ss' format.
+func (db *Database) CountArticleRecords(interval string, byProvider bool, byCategory bool, from time.Time, to time.Time) ([]ArticleCountRow, error) {
+	var rows []ArticleCountRow
+
+	var bucketExpr string
+	switch interval {
+	case stats.IntervalHour:
+		bucketExpr = "DATE_FORMAT(`articles`.`published_date`, '%Y-%m-%d %H:00:00')"
+	case stats.IntervalWeek:
+		bucketExpr = "DATE_FORMAT(DATE_SUB(DATE(`articles`.`published_date`), " +
+			"INTERVAL WEEKDAY(`articles`.`published_date`) DAY), '%Y-%m-%d 00:00:00')"
+	default:
+		bucketExpr = "DATE_FORMAT(`articles`.`published_date`, '%Y-%m-%d 00:00:00')"
+	}
+
+	selects := []string{bucketExpr + " AS bucket", "COUNT(*) AS count"}
+	groups := []string{"bucket"}
+
+	chain := db.conn.Model(&Article{})
+
+	if byProvider {
+		chain = chain.Joins("JOIN `providers` ON `providers`.`id` = `articles`.`provider_id`")
+		selects = append(selects, "`providers`.`name` AS provider")
+		groups = append(groups, "provider")
+	}
+
+	if byCategory {
+		chain = chain.Joins("JOIN `categories` ON `categories`.`id` = `articles`.`category_id`")
+		selects = append(selects, "`categories`.`name` AS category")
+		groups = append(groups, "category")
+	}
+
+	chain = chain.Select(strings.Join(selects, ", ")).
+		Where("`articles`.`published_date` >= ? AND `articles`.`published_date` < ?", from, to).
+		Group(strings.Join(groups, ", ")).
+		Order(strings.Join(groups, ", "))
+
+	result := chain.Scan(&rows)
+	return rows, result.Error
+}
+
+// InsertArticleRecord inserts a new article record in the database, along with its creation event in the
+// outbox, in a single transaction.
 func (db *Database) InsertArticleRecord(article entities.Article) error {
-	// Add Provider if it doesn't exist
-	var providerRecord Provider
-	result := db.conn.Where(Provider{Name: article.Provider}).FirstOrCreate(&providerRecord)
+	eventRecord, err := newArticleOutboxEvent(entities.EventArticleCreated, article, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return db.conn.Transaction(func(tx *gorm.DB) error {
+		// Add Provider if it doesn't exist
+		var providerRecord Provider
+		result := tx.Where(Provider{Name: article.Provider}).FirstOrCreate(&providerRecord)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Add Category if it doesn't exist
+		var categoryRecord Category
+		result = tx.Where(Category{Name: article.Category}).FirstOrCreate(&categoryRecord)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		articleRecord := Article{
+			GUID:          article.GUID,
+			Title:         article.Title,
+			Description:   article.Description,
+			Link:          article.Link,
+			PublishedDate: article.PublishedTime,
+			ProviderID:    providerRecord.ID,
+			CategoryID:    categoryRecord.ID,
+		}
+
+		result = tx.Create(&articleRecord)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Create(&eventRecord).Error
+	})
+}
+
+// InsertArticleRecords inserts multiple article records in the database, along with their creation events in
+// the outbox, in a single transaction.
+// Articles whose GUID already exists in the database (or earlier in the list) are skipped and their GUIDs
+// returned.
+func (db *Database) InsertArticleRecords(articles entities.Articles) (duplicates []string, err error) {
+	now := time.Now()
+
+	err = db.conn.Transaction(func(tx *gorm.DB) error {
+		guids := make([]string, 0, len(articles))
+		for _, article := range articles {
+			guids = append(guids, article.GUID)
+		}
+
+		var existingGUIDs []string
+		result := tx.Model(&Article{}).Where("guid IN ?", guids).Pluck("guid", &existingGUIDs)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		seen := make(map[string]bool, len(articles))
+		for _, guid := range existingGUIDs {
+			seen[guid] = true
+		}
+
+		providerIDs := make(map[string]uint64)
+		categoryIDs := make(map[string]uint64)
+		articleRecords := make([]Article, 0, len(articles))
+		eventRecords := make([]OutboxEvent, 0, len(articles))
+
+		for _, article := range articles {
+			if seen[article.GUID] {
+				duplicates = append(duplicates, article.GUID)
+				continue
+			}
+			seen[article.GUID] = true
+
+			// Add Provider if it doesn't exist
+			providerID, ok := providerIDs[article.Provider]
+			if !ok {
+				var providerRecord Provider
+				result := tx.Where(Provider{Name: article.Provider}).FirstOrCreate(&providerRecord)
+				if result.Error != nil {
+					return result.Error
+				}
+				providerID = providerRecord.ID
+				providerIDs[article.Provider] = providerID
+			}
+
+			// Add Category if it doesn't exist
+			categoryID, ok := categoryIDs[article.Category]
+			if !ok {
+				var categoryRecord Category
+				result := tx.Where(Category{Name: article.Category}).FirstOrCreate(&categoryRecord)
+				if result.Error != nil {
+					return result.Error
+				}
+				categoryID = categoryRecord.ID
+				categoryIDs[article.Category] = categoryID
+			}
+
+			articleRecords = append(articleRecords, Article{
+				GUID:          article.GUID,
+				Title:         article.Title,
+				Description:   article.Description,
+				Link:          article.Link,
+				PublishedDate: article.PublishedTime,
+				ProviderID:    providerID,
+				CategoryID:    categoryID,
+			})
+
+			eventRecord, err := newArticleOutboxEvent(entities.EventArticleCreated, article, now)
+			if err != nil {
+				return err
+			}
+			eventRecords = append(eventRecords, eventRecord)
+		}
+
+		if len(articleRecords) == 0 {
+			return nil
+		}
+
+		if err := tx.Create(&articleRecords).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&eventRecords).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return duplicates, nil
+}
+
+// FindAllFeedRecords finds all the feed records.
+func (db *Database) FindAllFeedRecords() ([]Feed, error) {
+	var feedResults []Feed
+	result := db.conn.Order("id asc").Find(&feedResults)
+	return feedResults, result.Error
+}
+
+// FindFeedRecord finds a feed record by ID.
+func (db *Database) FindFeedRecord(id uint64) (Feed, error) {
+	var feedRecord Feed
+	result := db.conn.First(&feedRecord, id)
+	return feedRecord, result.Error
+}
+
+// InsertFeedRecord inserts a new feed record in the database.
+func (db *Database) InsertFeedRecord(feedRecord *Feed) error {
+	result := db.conn.Create(feedRecord)
+	return result.Error
+}
+
+// UpdateFeedRecord updates the configurable fields of a feed record.
+// The fetch status fields are left untouched, except when re-enabling a feed, which clears its failure count
+// and backoff.
+func (db *Database) UpdateFeedRecord(feedRecord Feed) error {
+	current, err := db.FindFeedRecord(feedRecord.ID)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{"url", "provider", "category", "poll_interval", "enabled"}
+	if feedRecord.Enabled && !current.Enabled {
+		feedRecord.ConsecutiveFailures = 0
+		feedRecord.NextFetchAt = nil
+		columns = append(columns, "consecutive_failures", "next_fetch_at")
+	}
+
+	result := db.conn.Model(&Feed{ID: feedRecord.ID}).Select(columns).Updates(&feedRecord)
+	return result.Error
+}
+
+// UpdateFeedRecordStatus records the outcome of fetching a feed.
+func (db *Database) UpdateFeedRecordStatus(id uint64, status string, fetch entities.FeedFetch) error {
+	updates := map[string]interface{}{
+		"last_fetched_at":      fetch.FetchedAt,
+		"last_status":          status,
+		"last_error":           fetch.Error,
+		"etag":                 fetch.ETag,
+		"last_modified":        fetch.LastModified,
+		"consecutive_failures": fetch.ConsecutiveFailures,
+		"next_fetch_at":        fetch.NextFetchAt,
+	}
+
+	if fetch.Error == "" {
+		updates["last_success_at"] = fetch.FetchedAt
+	}
+
+	if fetch.Disable {
+		updates["enabled"] = false
+	}
+
+	result := db.conn.Model(&Feed{ID: id}).Updates(updates)
+	return result.Error
+}
+
+// DeleteFeedRecord deletes a feed record.
+func (db *Database) DeleteFeedRecord(id uint64) (deleted bool, err error) {
+	result := db.conn.Delete(&Feed{}, id)
+	return result.RowsAffected != 0, result.Error
+}
+
+// FindAllWebhookRecords finds all the webhook records.
+func (db *Database) FindAllWebhookRecords() ([]Webhook, error) {
+	var webhookResults []Webhook
+	result := db.conn.Order("id asc").Find(&webhookResults)
+	return webhookResults, result.Error
+}
+
+// FindWebhookRecord finds a webhook record by ID.
+func (db *Database) FindWebhookRecord(id uint64) (Webhook, error) {
+	var webhookRecord Webhook
+	result := db.conn.First(&webhookRecord, id)
+	return webhookRecord, result.Error
+}
+
+// InsertWebhookRecord inserts a new webhook record in the database.
+func (db *Database) InsertWebhookRecord(webhookRecord *Webhook) error {
+	result := db.conn.Create(webhookRecord)
+	return result.Error
+}
+
+// DeleteWebhookRecord deletes a webhook record along with its deliveries.
+func (db *Database) DeleteWebhookRecord(id uint64) (deleted bool, err error) {
+	err = db.conn.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("webhook_id = ?", id).Delete(&WebhookDelivery{})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Delete(&Webhook{}, id)
+		deleted = result.RowsAffected != 0
+		return result.Error
+	})
+
+	return deleted, err
+}
+
+// InsertWebhookDeliveryRecords inserts new webhook delivery records in the database.
+func (db *Database) InsertWebhookDeliveryRecords(deliveryRecords []WebhookDelivery) error {
+	result := db.conn.Create(&deliveryRecords)
+	return result.Error
+}
+
+// ClaimDueWebhookDeliveryRecords claims the pending webhook delivery records due at a given time, oldest first,
+// by moving their next attempt to claimedUntil. Only the records claimed are returned: those claimed or attempted
+// meanwhile by someone else aren't.
+func (db *Database) ClaimDueWebhookDeliveryRecords(now time.Time, claimedUntil time.Time,
+	limit int) ([]WebhookDelivery, error) {
+
+	var deliveryResults []WebhookDelivery
+	result := db.conn.
+		Where("status = ? AND next_attempt_at <= ?", entities.DeliveryStatusPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&deliveryResults)
 	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	claimed := make([]WebhookDelivery, 0, len(deliveryResults))
+	for _, deliveryRecord := range deliveryResults {
+		result := db.conn.Model(&WebhookDelivery{}).
+			Where("id = ? AND status = ? AND next_attempt_at <= ?", deliveryRecord.ID,
+				entities.DeliveryStatusPending, now).
+			Update("next_attempt_at", claimedUntil)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if result.RowsAffected == 1 {
+			deliveryRecord.NextAttemptAt = &claimedUntil
+			claimed = append(claimed, deliveryRecord)
+		}
+	}
+
+	return claimed, nil
+}
+
+// FindWebhookDeliveryRecords finds the most recent delivery records of a webhook.
+func (db *Database) FindWebhookDeliveryRecords(webhookID uint64, limit int) ([]WebhookDelivery, error) {
+	var deliveryResults []WebhookDelivery
+	result := db.conn.
+		Where("webhook_id = ?", webhookID).
+		Order("id desc").
+		Limit(limit).
+		Find(&deliveryResults)
+	return deliveryResults, result.Error
+}
+
+// UpdateWebhookDeliveryRecordStatus updates the status fields of a webhook delivery record.
+func (db *Database) UpdateWebhookDeliveryRecordStatus(deliveryRecord WebhookDelivery) error {
+	result := db.conn.Model(&WebhookDelivery{ID: deliveryRecord.ID}).
+		Select("status", "attempts", "response_status", "error", "last_attempt_at", "next_attempt_at").
+		Updates(&deliveryRecord)
+	return result.Error
+}
+
+// FindAllAPIKeyRecords finds all the API key records.
+func (db *Database) FindAllAPIKeyRecords() ([]APIKey, error) {
+	var keyResults []APIKey
+	result := db.conn.Order("id asc").Find(&keyResults)
+	return keyResults, result.Error
+}
+
+// FindAPIKeyRecordByHash finds an API key record by the hash of the key.
+func (db *Database) FindAPIKeyRecordByHash(hash string) (APIKey, error) {
+	var keyRecord APIKey
+	result := db.conn.Where("hash = ?", hash).First(&keyRecord)
+	return keyRecord, result.Error
+}
+
+// InsertAPIKeyRecord inserts a new API key record in the database.
+func (db *Database) InsertAPIKeyRecord(keyRecord *APIKey) error {
+	result := db.conn.Create(keyRecord)
+	return result.Error
+}
+
+// UpdateAPIKeyRecordEnabled enables or disables an API key record.
+func (db *Database) UpdateAPIKeyRecordEnabled(id uint64, enabled bool) error {
+	var keyRecord APIKey
+	if result := db.conn.First(&keyRecord, id); result.Error != nil {
 		return result.Error
 	}
 
-	// Add Category if it doesn't exist
-	var categoryRecord Category
-	result = db.conn.Where(Category{Name: article.Category}).FirstOrCreate(&categoryRecord)
-	if result.Error != nil {
+	result := db.conn.Model(&keyRecord).Update("enabled", enabled)
+	return result.Error
+}
+
+// ClaimPendingOutboxEventRecords claims the outbox event records not yet published, oldest first, for claimant
+// until claimedUntil. Nothing is claimed while any of them is claimed by someone else, so that only one relay at a
+// time publishes events and they're published in order.
+func (db *Database) ClaimPendingOutboxEventRecords(claimant string, now time.Time, claimedUntil time.Time,
+	limit int) ([]OutboxEvent, error) {
+
+	var eventResults []OutboxEvent
+	err := db.conn.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("published_at IS NULL").
+			Order("id asc").
+			Limit(limit).
+			Find(&eventResults)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		ids := make([]uint64, 0, len(eventResults))
+		for _, eventRecord := range eventResults {
+			if eventRecord.ClaimedBy != claimant && eventRecord.ClaimedUntil != nil &&
+				eventRecord.ClaimedUntil.After(now) {
+				eventResults = nil
+				return nil
+			}
+			ids = append(ids, eventRecord.ID)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		result = tx.Model(&OutboxEvent{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{"claimed_by": claimant, "claimed_until": claimedUntil})
 		return result.Error
+	}, &sql.TxOptions{Isolation: sql.LevelReadCommitted}) // No gap locks, so that events can be added meanwhile
+
+	if err != nil {
+		return nil, err
 	}
+	return eventResults, nil
+}
+
+// FindOutboxEventRecordsAfter finds the outbox event records with an ID greater than afterID, whether published
+// or not, oldest first.
+func (db *Database) FindOutboxEventRecordsAfter(afterID uint64, limit int) ([]OutboxEvent, error) {
+	var eventResults []OutboxEvent
+	result := db.conn.
+		Where("id > ?", afterID).
+		Order("id asc").
+		Limit(limit).
+		Find(&eventResults)
+	return eventResults, result.Error
+}
 
-	articleRecord := Article{
-		GUID:          article.GUID,
-		Title:         article.Title,
-		Description:   article.Description,
-		Link:          article.Link,
-		PublishedDate: article.PublishedTime,
-		ProviderID:    providerRecord.ID,
-		CategoryID:    categoryRecord.ID,
+// FindLastOutboxEventRecordID finds the ID of the last outbox event record, or 0 if there's none.
+func (db *Database) FindLastOutboxEventRecordID() (uint64, error) {
+	var id sql.NullInt64
+	result := db.conn.Model(&OutboxEvent{}).Select("MAX(id)").Row()
+	if err := result.Scan(&id); err != nil {
+		return 0, err
 	}
+	return uint64(id.Int64), nil
+}
 
-	result = db.conn.Create(&articleRecord)
+// UpdateOutboxEventRecordsPublished marks outbox event records as published.
+func (db *Database) UpdateOutboxEventRecordsPublished(ids []uint64, publishedAt time.Time) error {
+	result := db.conn.Model(&OutboxEvent{}).Where("id IN ?", ids).Update("published_at", publishedAt)
 	return result.Error
 }
+
+// DeleteOutboxEventRecordsPublishedBefore deletes the outbox event records published before the given time.
+func (db *Database) DeleteOutboxEventRecordsPublishedBefore(before time.Time) (int64, error) {
+	result := db.conn.Where("published_at < ?", before).Delete(&OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
+
+// newArticleOutboxEvent returns an outbox event record of the given type for an article.
+func newArticleOutboxEvent(eventType string, article entities.Article, occurredAt time.Time) (OutboxEvent, error) {
+	payload, err := json.Marshal(article)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+
+	return OutboxEvent{
+		Type:        eventType,
+		ArticleGUID: article.GUID,
+		Payload:     string(payload),
+		OccurredAt:  occurredAt,
+	}, nil
+}
@@ -26,3 +26,103 @@ type Category struct {
 	ID   uint64 `gorm:"primaryKey;autoIncrement;not null"`
 	Name string `gorm:"type:varchar(30);uniqueIndex;not null"`
 }
+
+// Feed represents the 'feeds' table in the database.
+type Feed struct {
+	ID       uint64 `gorm:"primaryKey;autoIncrement;not null"`
+	URL      string `gorm:"type:varchar(500);uniqueIndex;not null"`
+	Provider string `gorm:"type:varchar(30);not null"`
+	Category string `gorm:"type:varchar(30);not null"`
+	// PollInterval is in seconds, 0 means the poller's default.
+	PollInterval  int    `gorm:"not null"`
+	Enabled       bool   `gorm:"not null"`
+	LastStatus    string `gorm:"type:varchar(10);not null"`
+	LastError     string `gorm:"type:varchar(1000);not null"`
+	LastFetchedAt *time.Time
+	LastSuccessAt *time.Time
+	// ETag and LastModified are the validators of the last fetched document.
+	ETag                string `gorm:"type:varchar(200);not null"`
+	LastModified        string `gorm:"type:varchar(50);not null"`
+	ConsecutiveFailures int    `gorm:"not null"`
+	// NextFetchAt is set while backing off from failures.
+	NextFetchAt *time.Time
+}
+
+// Webhook represents the 'webhooks' table in the database.
+type Webhook struct {
+	ID  uint64 `gorm:"primaryKey;autoIncrement;not null"`
+	URL string `gorm:"type:varchar(500);not null"`
+	// Provider and Category filter the articles delivered, empty means all.
+	Provider  string    `gorm:"type:varchar(30);not null"`
+	Category  string    `gorm:"type:varchar(30);not null"`
+	Secret    string    `gorm:"type:varchar(100);not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// WebhookDelivery represents the 'webhook_deliveries' table in the database.
+type WebhookDelivery struct {
+	ID             uint64    `gorm:"primaryKey;autoIncrement;not null"`
+	WebhookID      uint64    `gorm:"index;not null"` // Foreign Key
+	ArticleGUID    string    `gorm:"type:varchar(500);not null"`
+	Payload        string    `gorm:"not null"`
+	Status         string    `gorm:"type:varchar(10);index:idx_webhook_deliveries_due,priority:1;not null"`
+	Attempts       int       `gorm:"not null"`
+	ResponseStatus int       `gorm:"not null"`
+	Error          string    `gorm:"type:varchar(1000);not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+	LastAttemptAt  *time.Time
+	NextAttemptAt  *time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+}
+
+// APIKey represents the 'api_keys' table in the database.
+type APIKey struct {
+	ID    uint64 `gorm:"primaryKey;autoIncrement;not null"`
+	Label string `gorm:"type:varchar(100);not null"`
+	// Prefix is the start of the key, kept in the clear so that keys can be told apart.
+	Prefix string `gorm:"type:varchar(12);not null"`
+	// Hash is the hex encoded SHA-256 of the key.
+	Hash      string    `gorm:"type:char(64);uniqueIndex;not null"`
+	Enabled   bool      `gorm:"not null"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// ArticleRow represents a flattened row of the articles table joined with providers and categories.
+type ArticleRow struct {
+	GUID          string
+	Title         string
+	Description   string
+	Link          string
+	PublishedDate time.Time
+	ProviderName  string
+	CategoryName  string
+}
+
+// ArticleCountRow represents a row of the article counts aggregation query.
+type ArticleCountRow struct {
+	Bucket   string
+	Provider string
+	Category string
+	Count    int64
+}
+
+// ArticlesVersionRow represents the row of the articles version query.
+type ArticlesVersionRow struct {
+	Count             int64
+	LastPublishedDate *time.Time
+}
+
+// OutboxEvent represents the 'outbox_events' table in the database.
+// Events are written in the same transaction as the change they describe and relayed to the event
+// publisher afterwards.
+type OutboxEvent struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement;not null"`
+	Type        string    `gorm:"type:varchar(30);not null"`
+	ArticleGUID string    `gorm:"type:varchar(500);not null"`
+	Payload     string    `gorm:"not null"`
+	OccurredAt  time.Time `gorm:"not null"`
+	// PublishedAt is set once the event has been relayed.
+	PublishedAt *time.Time `gorm:"index"`
+	// ClaimedBy is the relay the event is being published by, until ClaimedUntil.
+	ClaimedBy    string `gorm:"type:varchar(100);not null;default:''"`
+	ClaimedUntil *time.Time
+}
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/VividCortex/mysqlerr"
 	"github.com/go-sql-driver/mysql"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
 	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
 	"gorm.io/gorm"
 )
@@ -50,14 +54,36 @@ func NewDatabaseService(host string, port int, username string, password string,
 		return nil, err
 	}
 
+	if err = dbs.Database.Migrate(); err != nil {
+		return nil, err
+	}
+
 	return dbs, nil
 }
 
+// WithContext returns a DatabaseService running its statements with ctx, so that they're traced as children of
+// the span in it.
+func (dbs *DatabaseService) WithContext(ctx context.Context) core.Repository {
+	return &DatabaseService{Database: dbs.Database.WithContext(ctx)}
+}
+
 // Close closes all database connections.
 func (dbs *DatabaseService) Close() error {
 	return dbs.Database.Close()
 }
 
+// Stats returns the statistics of the connection pool, or empty ones if they can't be read.
+func (dbs *DatabaseService) Stats() sql.DBStats {
+	stats, _ := dbs.Database.Stats()
+	return stats
+}
+
+// SetCredentials changes the credentials the connections to the database are opened with, reconnecting if
+// they changed (e.g. when they're rotated) and the database accepts them.
+func (dbs *DatabaseService) SetCredentials(username string, password string) (changed bool, err error) {
+	return dbs.Database.SetCredentials(username, password)
+}
+
 // HealthCheck checks whether the database is still around.
 func (dbs *DatabaseService) HealthCheck() error {
 	return dbs.Database.HealthCheck()
@@ -91,6 +117,22 @@ func (dbs *DatabaseService) GetArticles(provider string, category string, sortin
 	return articleList, nil
 }
 
+// GetArticlesVersion returns the version of the articles GetArticles returns with the same criteria, without
+// getting them.
+func (dbs *DatabaseService) GetArticlesVersion(provider string, category string, sorting string, after *time.Time) (version entities.ArticlesVersion, err error) {
+	row, err := dbs.Database.FindArticleRecordsVersion(provider, category, sorting, after)
+	if err != nil {
+		return entities.ArticlesVersion{}, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	version.Count = row.Count
+	if row.LastPublishedDate != nil {
+		version.LastPublished = *row.LastPublishedDate
+	}
+
+	return version, nil
+}
+
 // AddArticle adds a new article record to the database.
 func (dbs *DatabaseService) AddArticle(article entities.Article) (err error) {
 	err = dbs.Database.InsertArticleRecord(article)
@@ -105,3 +147,498 @@ func (dbs *DatabaseService) AddArticle(article entities.Article) (err error) {
 
 	return nil
 }
+
+// AddArticles adds multiple article records to the database in a single transaction.
+// Articles whose GUID already exists are skipped and their GUIDs returned in duplicates.
+func (dbs *DatabaseService) AddArticles(articles entities.Articles) (duplicates []string, err error) {
+	if len(articles) == 0 {
+		return nil, nil
+	}
+
+	duplicates, err = dbs.Database.InsertArticleRecords(articles)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return duplicates, nil
+}
+
+// StreamArticles calls fn for every article record matching a certain criteria, without loading them all
+// in memory.
+// Articles are sorted by published date and GUID (ascending) so that a stream can be resumed from the last
+// article seen. Errors returned by fn are returned unwrapped.
+func (dbs *DatabaseService) StreamArticles(provider string, category string, after *time.Time, afterGUID string, fn func(article entities.Article) error) (err error) {
+	var fnErr error
+
+	err = dbs.Database.IterateArticleRecords(provider, category, after, afterGUID, func(row ArticleRow) error {
+		fnErr = fn(entities.Article{
+			GUID:          row.GUID,
+			Title:         row.Title,
+			Description:   row.Description,
+			Link:          row.Link,
+			PublishedTime: row.PublishedDate,
+			Provider:      row.ProviderName,
+			Category:      row.CategoryName,
+		})
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	} else if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return nil
+}
+
+// GetArticleStats returns the number of articles published in [from, to) per time bucket, optionally grouped
+// by provider and/or category.
+func (dbs *DatabaseService) GetArticleStats(interval string, byProvider bool, byCategory bool, from time.Time, to time.Time) (counts entities.ArticleCounts, err error) {
+	rows, err := dbs.Database.CountArticleRecords(interval, byProvider, byCategory, from, to)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	counts = make(entities.ArticleCounts, 0, len(rows))
+
+	for _, row := range rows {
+		bucketStart, err := time.ParseInLocation("2006-01-02 15:04:05", row.Bucket, time.UTC)
+		if err != nil {
+			return nil, &DBServiceError{Msg: "database error: unexpected bucket format", Err: err}
+		}
+
+		counts = append(counts, entities.ArticleCount{
+			BucketStart: bucketStart,
+			Provider:    row.Provider,
+			Category:    row.Category,
+			Count:       row.Count,
+		})
+	}
+
+	return counts, nil
+}
+
+// GetFeeds returns all feed records.
+func (dbs *DatabaseService) GetFeeds() (feeds entities.Feeds, err error) {
+	feedRecords, err := dbs.Database.FindAllFeedRecords()
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	feeds = make(entities.Feeds, 0, len(feedRecords))
+	for _, feedRecord := range feedRecords {
+		feeds = append(feeds, feedFromRecord(feedRecord))
+	}
+
+	return feeds, nil
+}
+
+// GetFeed returns a feed record.
+func (dbs *DatabaseService) GetFeed(id uint64) (feed entities.Feed, err error) {
+	feedRecord, err := dbs.Database.FindFeedRecord(id)
+	if err != nil {
+		return feed, translateError(err)
+	}
+
+	return feedFromRecord(feedRecord), nil
+}
+
+// AddFeed adds a new feed record to the database and returns it with its ID.
+func (dbs *DatabaseService) AddFeed(feed entities.Feed) (entities.Feed, error) {
+	feedRecord := feedToRecord(feed)
+
+	if err := dbs.Database.InsertFeedRecord(&feedRecord); err != nil {
+		return feed, translateError(err)
+	}
+
+	return feedFromRecord(feedRecord), nil
+}
+
+// UpdateFeed updates the configuration of a feed record.
+func (dbs *DatabaseService) UpdateFeed(feed entities.Feed) (err error) {
+	err = dbs.Database.UpdateFeedRecord(feedToRecord(feed))
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// UpdateFeedStatus records the outcome of fetching a feed. An empty fetch error means success.
+func (dbs *DatabaseService) UpdateFeedStatus(id uint64, fetch entities.FeedFetch) (err error) {
+	status := entities.FeedStatusOK
+	if fetch.Error != "" {
+		status = entities.FeedStatusError
+		// The column only holds so much
+		if len(fetch.Error) > 1000 {
+			fetch.Error = fetch.Error[:1000]
+		}
+	}
+
+	err = dbs.Database.UpdateFeedRecordStatus(id, status, fetch)
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return nil
+}
+
+// DeleteFeed deletes a feed record.
+func (dbs *DatabaseService) DeleteFeed(id uint64) (err error) {
+	deleted, err := dbs.Database.DeleteFeedRecord(id)
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	} else if !deleted {
+		return &DBNotFoundError{}
+	}
+
+	return nil
+}
+
+// GetWebhooks returns all webhook records.
+func (dbs *DatabaseService) GetWebhooks() (webhooks entities.Webhooks, err error) {
+	webhookRecords, err := dbs.Database.FindAllWebhookRecords()
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	webhooks = make(entities.Webhooks, 0, len(webhookRecords))
+	for _, webhookRecord := range webhookRecords {
+		webhooks = append(webhooks, webhookFromRecord(webhookRecord))
+	}
+
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook record.
+func (dbs *DatabaseService) GetWebhook(id uint64) (webhook entities.Webhook, err error) {
+	webhookRecord, err := dbs.Database.FindWebhookRecord(id)
+	if err != nil {
+		return webhook, translateError(err)
+	}
+
+	return webhookFromRecord(webhookRecord), nil
+}
+
+// AddWebhook adds a new webhook record to the database and returns it with its ID.
+func (dbs *DatabaseService) AddWebhook(webhook entities.Webhook) (entities.Webhook, error) {
+	webhookRecord := Webhook{
+		URL:       webhook.URL,
+		Provider:  webhook.Provider,
+		Category:  webhook.Category,
+		Secret:    webhook.Secret,
+		CreatedAt: webhook.CreatedAt,
+	}
+
+	if err := dbs.Database.InsertWebhookRecord(&webhookRecord); err != nil {
+		return webhook, translateError(err)
+	}
+
+	return webhookFromRecord(webhookRecord), nil
+}
+
+// DeleteWebhook deletes a webhook record and its deliveries.
+func (dbs *DatabaseService) DeleteWebhook(id uint64) (err error) {
+	deleted, err := dbs.Database.DeleteWebhookRecord(id)
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	} else if !deleted {
+		return &DBNotFoundError{}
+	}
+
+	return nil
+}
+
+// AddWebhookDeliveries adds new webhook delivery records to the database.
+func (dbs *DatabaseService) AddWebhookDeliveries(deliveries entities.WebhookDeliveries) (err error) {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	deliveryRecords := make([]WebhookDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryRecords = append(deliveryRecords, deliveryToRecord(delivery))
+	}
+
+	err = dbs.Database.InsertWebhookDeliveryRecords(deliveryRecords)
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return nil
+}
+
+// ClaimDueWebhookDeliveries claims up to limit pending webhook deliveries due at a given time, oldest first, by
+// putting their next attempt off for the lease. Deliveries claimed by someone else meanwhile aren't returned.
+func (dbs *DatabaseService) ClaimDueWebhookDeliveries(now time.Time, lease time.Duration, limit int) (deliveries entities.WebhookDeliveries, err error) {
+	deliveryRecords, err := dbs.Database.ClaimDueWebhookDeliveryRecords(now, now.Add(lease), limit)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return deliveriesFromRecords(deliveryRecords), nil
+}
+
+// GetWebhookDeliveries returns up to limit of the most recent deliveries of a webhook.
+func (dbs *DatabaseService) GetWebhookDeliveries(webhookID uint64, limit int) (deliveries entities.WebhookDeliveries, err error) {
+	deliveryRecords, err := dbs.Database.FindWebhookDeliveryRecords(webhookID, limit)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return deliveriesFromRecords(deliveryRecords), nil
+}
+
+// UpdateWebhookDelivery updates the status of a webhook delivery record.
+func (dbs *DatabaseService) UpdateWebhookDelivery(delivery entities.WebhookDelivery) (err error) {
+	// The column only holds so much
+	if len(delivery.Error) > 1000 {
+		delivery.Error = delivery.Error[:1000]
+	}
+
+	err = dbs.Database.UpdateWebhookDeliveryRecordStatus(deliveryToRecord(delivery))
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return nil
+}
+
+// GetAPIKeys returns all API key records.
+func (dbs *DatabaseService) GetAPIKeys() (keys entities.APIKeys, err error) {
+	keyRecords, err := dbs.Database.FindAllAPIKeyRecords()
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	keys = make(entities.APIKeys, 0, len(keyRecords))
+	for _, keyRecord := range keyRecords {
+		keys = append(keys, apiKeyFromRecord(keyRecord))
+	}
+
+	return keys, nil
+}
+
+// GetAPIKeyByHash returns the API key record with the given hash.
+func (dbs *DatabaseService) GetAPIKeyByHash(hash string) (key entities.APIKey, err error) {
+	keyRecord, err := dbs.Database.FindAPIKeyRecordByHash(hash)
+	if err != nil {
+		return key, translateError(err)
+	}
+
+	return apiKeyFromRecord(keyRecord), nil
+}
+
+// AddAPIKey adds a new API key record to the database and returns it with its ID.
+func (dbs *DatabaseService) AddAPIKey(key entities.APIKey) (entities.APIKey, error) {
+	keyRecord := APIKey{
+		Label:     key.Label,
+		Prefix:    key.Prefix,
+		Hash:      key.Hash,
+		Enabled:   key.Enabled,
+		CreatedAt: key.CreatedAt,
+	}
+
+	if err := dbs.Database.InsertAPIKeyRecord(&keyRecord); err != nil {
+		return key, translateError(err)
+	}
+
+	return apiKeyFromRecord(keyRecord), nil
+}
+
+// SetAPIKeyEnabled enables or disables an API key record.
+func (dbs *DatabaseService) SetAPIKeyEnabled(id uint64, enabled bool) (err error) {
+	err = dbs.Database.UpdateAPIKeyRecordEnabled(id, enabled)
+	if err != nil {
+		return translateError(err)
+	}
+
+	return nil
+}
+
+// ClaimPendingEvents claims up to limit events not yet published, in the order they occurred, for claimant to
+// publish within the lease. No events are returned while they're claimed by another claimant whose lease hasn't
+// expired.
+func (dbs *DatabaseService) ClaimPendingEvents(claimant string, now time.Time, lease time.Duration,
+	limit int) (events entities.Events, err error) {
+
+	eventRecords, err := dbs.Database.ClaimPendingOutboxEventRecords(claimant, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return eventsFromRecords(eventRecords)
+}
+
+// GetEventsAfter returns the events with an ID greater than afterID, whether published or not, oldest first.
+func (dbs *DatabaseService) GetEventsAfter(afterID uint64, limit int) (events entities.Events, err error) {
+	eventRecords, err := dbs.Database.FindOutboxEventRecordsAfter(afterID, limit)
+	if err != nil {
+		return nil, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return eventsFromRecords(eventRecords)
+}
+
+// GetLastEventID returns the ID of the last event, or 0 if there's none.
+func (dbs *DatabaseService) GetLastEventID() (id uint64, err error) {
+	id, err = dbs.Database.FindLastOutboxEventRecordID()
+	if err != nil {
+		return 0, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return id, nil
+}
+
+// MarkEventsPublished marks events as published so that they're not returned as pending anymore.
+func (dbs *DatabaseService) MarkEventsPublished(ids []uint64, publishedAt time.Time) (err error) {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err = dbs.Database.UpdateOutboxEventRecordsPublished(ids, publishedAt)
+	if err != nil {
+		return &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return nil
+}
+
+// DeletePublishedEvents deletes the events published before the given time.
+func (dbs *DatabaseService) DeletePublishedEvents(before time.Time) (deleted int64, err error) {
+	deleted, err = dbs.Database.DeleteOutboxEventRecordsPublishedBefore(before)
+	if err != nil {
+		return 0, &DBServiceError{Msg: "database error", Err: err}
+	}
+
+	return deleted, nil
+}
+
+// eventsFromRecords converts outbox event records into entities.Events.
+func eventsFromRecords(eventRecords []OutboxEvent) (entities.Events, error) {
+	events := make(entities.Events, 0, len(eventRecords))
+	for _, eventRecord := range eventRecords {
+		event := entities.Event{
+			ID:         eventRecord.ID,
+			Type:       eventRecord.Type,
+			OccurredAt: eventRecord.OccurredAt,
+		}
+
+		if err := json.Unmarshal([]byte(eventRecord.Payload), &event.Article); err != nil {
+			return nil, &DBServiceError{Msg: "database error", Err: err}
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// translateError translates gorm and driver errors into the service errors.
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &DBNotFoundError{}
+	}
+
+	if driverErr, ok := err.(*mysql.MySQLError); ok {
+		if driverErr.Number == mysqlerr.ER_DUP_ENTRY {
+			return &DBDUPError{}
+		}
+	}
+
+	return &DBServiceError{Msg: "database error", Err: err}
+}
+
+// feedFromRecord converts a feed record into an entities.Feed.
+func feedFromRecord(feedRecord Feed) entities.Feed {
+	return entities.Feed{
+		ID:                  feedRecord.ID,
+		URL:                 feedRecord.URL,
+		Provider:            feedRecord.Provider,
+		Category:            feedRecord.Category,
+		PollIntervalSeconds: feedRecord.PollInterval,
+		Enabled:             feedRecord.Enabled,
+		LastStatus:          feedRecord.LastStatus,
+		LastError:           feedRecord.LastError,
+		LastFetchedAt:       feedRecord.LastFetchedAt,
+		LastSuccessAt:       feedRecord.LastSuccessAt,
+		ETag:                feedRecord.ETag,
+		LastModified:        feedRecord.LastModified,
+		ConsecutiveFailures: feedRecord.ConsecutiveFailures,
+		NextFetchAt:         feedRecord.NextFetchAt,
+	}
+}
+
+// feedToRecord converts the configurable fields of an entities.Feed into a feed record.
+func feedToRecord(feed entities.Feed) Feed {
+	return Feed{
+		ID:           feed.ID,
+		URL:          feed.URL,
+		Provider:     feed.Provider,
+		Category:     feed.Category,
+		PollInterval: feed.PollIntervalSeconds,
+		Enabled:      feed.Enabled,
+	}
+}
+
+// webhookFromRecord converts a webhook record into an entities.Webhook.
+func webhookFromRecord(webhookRecord Webhook) entities.Webhook {
+	return entities.Webhook{
+		ID:        webhookRecord.ID,
+		URL:       webhookRecord.URL,
+		Provider:  webhookRecord.Provider,
+		Category:  webhookRecord.Category,
+		Secret:    webhookRecord.Secret,
+		CreatedAt: webhookRecord.CreatedAt,
+	}
+}
+
+// apiKeyFromRecord converts an API key record into an entities.APIKey.
+func apiKeyFromRecord(keyRecord APIKey) entities.APIKey {
+	return entities.APIKey{
+		ID:        keyRecord.ID,
+		Label:     keyRecord.Label,
+		Prefix:    keyRecord.Prefix,
+		Hash:      keyRecord.Hash,
+		Enabled:   keyRecord.Enabled,
+		CreatedAt: keyRecord.CreatedAt,
+	}
+}
+
+// deliveryToRecord converts an entities.WebhookDelivery into a webhook delivery record.
+func deliveryToRecord(delivery entities.WebhookDelivery) WebhookDelivery {
+	return WebhookDelivery{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		ArticleGUID:    delivery.ArticleGUID,
+		Payload:        delivery.Payload,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		Error:          delivery.Error,
+		CreatedAt:      delivery.CreatedAt,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		NextAttemptAt:  delivery.NextAttemptAt,
+	}
+}
+
+// deliveriesFromRecords converts webhook delivery records into entities.WebhookDeliveries.
+func deliveriesFromRecords(deliveryRecords []WebhookDelivery) entities.WebhookDeliveries {
+	deliveries := make(entities.WebhookDeliveries, 0, len(deliveryRecords))
+	for _, deliveryRecord := range deliveryRecords {
+		deliveries = append(deliveries, entities.WebhookDelivery{
+			ID:             deliveryRecord.ID,
+			WebhookID:      deliveryRecord.WebhookID,
+			ArticleGUID:    deliveryRecord.ArticleGUID,
+			Payload:        deliveryRecord.Payload,
+			Status:         deliveryRecord.Status,
+			Attempts:       deliveryRecord.Attempts,
+			ResponseStatus: deliveryRecord.ResponseStatus,
+			Error:          deliveryRecord.Error,
+			CreatedAt:      deliveryRecord.CreatedAt,
+			LastAttemptAt:  deliveryRecord.LastAttemptAt,
+			NextAttemptAt:  deliveryRecord.NextAttemptAt,
+		})
+	}
+	return deliveries
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracerName is the name of the tracer creating the spans of SQL statements.
+const tracerName = "github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+
+// spanKey is the gorm instance key under which the span of the statement being run is stored.
+const spanKey = "tracing:span"
+
+// registerTracing registers gorm callbacks tracing every SQL statement, as a child of the span in the context
+// of the statement (see Database.WithContext). Spans are no-ops until a tracer provider is set.
+func registerTracing(db *gorm.DB) error {
+	tracer := otel.Tracer(tracerName)
+
+	before := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			_, span := tracer.Start(tx.Statement.Context, "gorm."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.DBSystemMySQL))
+			tx.InstanceSet(spanKey, span)
+		}
+	}
+
+	after := func(operation string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			value, ok := tx.InstanceGet(spanKey)
+			if !ok {
+				return
+			}
+			span := value.(trace.Span)
+			defer span.End()
+
+			// The statement has placeholders rather than values, so no data ends up in traces
+			span.SetAttributes(semconv.DBStatement(tx.Statement.SQL.String()))
+			if tx.Statement.Table != "" {
+				span.SetAttributes(semconv.DBSQLTable(tx.Statement.Table))
+			}
+
+			if tx.Error != nil && !errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+				span.RecordError(tx.Error)
+				span.SetStatus(codes.Error, tx.Error.Error())
+			}
+		}
+	}
+
+	return registerCallbacks(db, "tracing", before, after)
+}
@@ -0,0 +1,99 @@
+// Package stats provides helpers to aggregate article counts over time buckets.
+//
+// The SQL repository computes these aggregations in the database, this package provides the equivalent
+// implementation for repositories that cannot (e.g. in-memory ones) and the rules shared by both.
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// Interval names.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// ValidInterval reports whether interval is one of the supported intervals.
+func ValidInterval(interval string) bool {
+	return interval == IntervalHour || interval == IntervalDay || interval == IntervalWeek
+}
+
+// BucketStart returns the start of the bucket t falls into.
+// Weeks start on Monday (ISO 8601). Times are bucketed in UTC.
+func BucketStart(t time.Time, interval string) time.Time {
+	t = t.UTC()
+
+	switch interval {
+	case IntervalHour:
+		return t.Truncate(time.Hour)
+	case IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		// time.Weekday starts on Sunday, shift it so that Monday is 0.
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// Aggregate counts the articles published in [from, to) per time bucket, optionally grouped by provider
+// and/or category.
+// The result is sorted by bucket start, provider and category.
+func Aggregate(articles entities.Articles, interval string, byProvider bool, byCategory bool,
+	from time.Time, to time.Time) entities.ArticleCounts {
+
+	type key struct {
+		bucket   time.Time
+		provider string
+		category string
+	}
+
+	counts := make(map[key]int64)
+
+	for _, article := range articles {
+		if article.PublishedTime.Before(from) || !article.PublishedTime.Before(to) {
+			continue
+		}
+
+		k := key{bucket: BucketStart(article.PublishedTime, interval)}
+		if byProvider {
+			k.provider = article.Provider
+		}
+		if byCategory {
+			k.category = article.Category
+		}
+
+		counts[k]++
+	}
+
+	result := make(entities.ArticleCounts, 0, len(counts))
+	for k, count := range counts {
+		result = append(result, entities.ArticleCount{
+			BucketStart: k.bucket,
+			Provider:    k.provider,
+			Category:    k.category,
+			Count:       count,
+		})
+	}
+
+	Sort(result)
+	return result
+}
+
+// Sort sorts article counts by bucket start, provider and category.
+func Sort(counts entities.ArticleCounts) {
+	sort.Slice(counts, func(i, j int) bool {
+		if !counts[i].BucketStart.Equal(counts[j].BucketStart) {
+			return counts[i].BucketStart.Before(counts[j].BucketStart)
+		}
+		if counts[i].Provider != counts[j].Provider {
+			return counts[i].Provider < counts[j].Provider
+		}
+		return counts[i].Category < counts[j].Category
+	})
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// FileSink appends published events to a file as newline delimited JSON (NDJSON).
+type FileSink struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// NewFileSink returns a new FileSink appending to the file at path, which is created if it doesn't exist.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileSink{file: file, encoder: json.NewEncoder(file)}, nil
+}
+
+// Publish writes the event as a single line.
+func (s *FileSink) Publish(event entities.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.encoder.Encode(event)
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
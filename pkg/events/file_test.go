@@ -0,0 +1,38 @@
+package events_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	// Events are appended to the existing file
+	for _, id := range []uint64{1, 2} {
+		sink, err := events.NewFileSink(path)
+		require.NoError(t, err)
+		require.NoError(t, sink.Publish(entities.Event{ID: id, Type: entities.EventArticleCreated}))
+		require.NoError(t, sink.Close())
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for _, id := range []uint64{1, 2} {
+		event := entities.Event{}
+		require.NoError(t, decoder.Decode(&event))
+		assert.Equal(t, id, event.ID)
+		assert.Equal(t, entities.EventArticleCreated, event.Type)
+	}
+	assert.False(t, decoder.More())
+}
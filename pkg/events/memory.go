@@ -0,0 +1,42 @@
+// Package events publishes article lifecycle events to the rest of the platform.
+//
+// Events are recorded by the repository in an outbox, in the same transaction as the change they describe,
+// whatever the article comes from (the API, feeds or imports). A Relay publishes them once to EventPublishers
+// (e.g. an event sink and the webhook dispatcher) and purges them once relayed, while a Tailer follows them
+// on every instance of the service (e.g. for server-sent events). Delivery is at least once: consumers should
+// use the event ID to discard duplicates.
+package events
+
+import (
+	"sync"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// MemoryPublisher keeps published events in memory. It's meant for tests and development.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events entities.Events
+}
+
+// NewMemoryPublisher returns a new MemoryPublisher.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish stores the event.
+func (p *MemoryPublisher) Publish(event entities.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the events published so far, in order.
+func (p *MemoryPublisher) Events() entities.Events {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append(entities.Events{}, p.events...)
+}
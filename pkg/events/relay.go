@@ -0,0 +1,150 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// Relay publishes the events pending in the repository's outbox, and purges them once relayed.
+//
+// Every instance of the service runs a relay: a relay claims the events it publishes for a while (the lease), and
+// no relay claims any events while others hold a claim, so that events are published once and in order. Events
+// are only published again when their relay fails to mark them as published before its lease expires.
+type Relay struct {
+	Logger log.Logger
+	Repo   core.Repository
+	// Claimant identifies the relay in the claims of the events it publishes.
+	Claimant string
+	// Lease is how long the events claimed are reserved to the relay to publish them.
+	Lease time.Duration
+	// Publishers are published every event, in order.
+	Publishers []core.EventPublisher
+	// Tick is how often the outbox is checked for pending events.
+	Tick time.Duration
+	// BatchSize is the maximum number of events read from the outbox at once.
+	BatchSize int
+	// Retention is how long events are kept once relayed (0 keeps them for good).
+	Retention time.Duration
+	// PurgeInterval is how often the events relayed longer than Retention ago are deleted.
+	PurgeInterval time.Duration
+}
+
+// NewRelay returns a new Relay.
+func NewRelay(logger log.Logger, repo core.Repository, publishers ...core.EventPublisher) *Relay {
+	return &Relay{
+		Logger:        logger,
+		Repo:          repo,
+		Claimant:      newClaimant(),
+		Lease:         time.Minute,
+		Publishers:    publishers,
+		Tick:          time.Second,
+		BatchSize:     100,
+		PurgeInterval: time.Hour,
+	}
+}
+
+// newClaimant returns a claimant unique to the relay: the host name followed by a random suffix.
+func newClaimant() string {
+	hostname, _ := os.Hostname()
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+
+	return fmt.Sprintf("%s-%s", hostname, hex.EncodeToString(suffix))
+}
+
+// Run relays the pending events straight away and then every tick, and purges the events relayed longer than
+// the retention ago every purge interval, until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Tick)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		now := time.Now()
+		if _, err := r.RelayPending(ctx, now); err != nil {
+			r.Logger.Error(fmt.Sprintf("error relaying events: %s", err.Error()), log.Field("type", "events"))
+		}
+
+		if r.Retention > 0 && now.Sub(lastPurge) >= r.PurgeInterval {
+			lastPurge = now
+			if _, err := r.Purge(now); err != nil {
+				r.Logger.Error(fmt.Sprintf("error purging events: %s", err.Error()), log.Field("type", "events"))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending claims the pending events, publishes them in order and marks them as published, until the outbox is
+// empty or its events are claimed by another relay.
+// It stops at the first event that fails to be published, so that events are never published out of order.
+// An event is only marked as published once every publisher has published it: the publishers before the one
+// that failed are published it again on the next attempt.
+func (r *Relay) RelayPending(ctx context.Context, now time.Time) (published int, err error) {
+	for ctx.Err() == nil {
+		events, err := r.Repo.ClaimPendingEvents(r.Claimant, now, r.Lease, r.BatchSize)
+		if err != nil {
+			return published, err
+		}
+
+		if len(events) == 0 {
+			// Relayed, or being relayed by another relay
+			break
+		}
+
+		ids := make([]uint64, 0, len(events))
+		var publishErr error
+
+		for _, event := range events {
+			if publishErr = r.publish(event); publishErr != nil {
+				break
+			}
+			ids = append(ids, event.ID)
+		}
+
+		if err := r.Repo.MarkEventsPublished(ids, now); err != nil {
+			// These events will be published again
+			return published, err
+		}
+		published += len(ids)
+
+		if publishErr != nil {
+			return published, publishErr
+		}
+
+		if len(events) < r.BatchSize {
+			break
+		}
+	}
+
+	return published, nil
+}
+
+// publish publishes an event to every publisher, in order, stopping at the first failure.
+func (r *Relay) publish(event entities.Event) error {
+	for _, publisher := range r.Publishers {
+		if err := publisher.Publish(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge deletes the events relayed longer than the retention ago.
+func (r *Relay) Purge(now time.Time) (deleted int64, err error) {
+	return r.Repo.DeletePublishedEvents(now.Add(-r.Retention))
+}
@@ -0,0 +1,123 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPublisher fails to publish the event with the given ID.
+type failingPublisher struct {
+	*events.MemoryPublisher
+	failID uint64
+}
+
+func (p failingPublisher) Publish(event entities.Event) error {
+	if event.ID == p.failID {
+		return errors.New("broker unavailable")
+	}
+	return p.MemoryPublisher.Publish(event)
+}
+
+func TestRelayPending(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	pending := entities.Events{
+		{ID: 1, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 1"}},
+		{ID: 2, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 2"}},
+		{ID: 3, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid 3"}},
+	}
+
+	t.Run("publishes all pending events in batches", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("ClaimPendingEvents", "relay 1", now, time.Minute, 2).Return(pending[:2], nil).Once()
+		mockDB.On("ClaimPendingEvents", "relay 1", now, time.Minute, 2).Return(pending[2:], nil).Once()
+		mockDB.On("MarkEventsPublished", []uint64{1, 2}, now).Return(nil)
+		mockDB.On("MarkEventsPublished", []uint64{3}, now).Return(nil)
+
+		publisher := events.NewMemoryPublisher()
+		relay := events.NewRelay(log.NullLogger{}, mockDB, publisher)
+		relay.Claimant = "relay 1"
+		relay.BatchSize = 2
+
+		published, err := relay.RelayPending(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 3, published)
+		assert.Equal(t, pending, publisher.Events())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("ClaimPendingEvents", "relay 1", now, time.Minute, 100).Return(pending, nil)
+		mockDB.On("MarkEventsPublished", []uint64{1}, now).Return(nil)
+
+		publisher := failingPublisher{MemoryPublisher: events.NewMemoryPublisher(), failID: 2}
+		relay := events.NewRelay(log.NullLogger{}, mockDB, publisher)
+		relay.Claimant = "relay 1"
+
+		published, err := relay.RelayPending(context.Background(), now)
+		assert.Error(t, err)
+		assert.Equal(t, 1, published)
+		assert.Len(t, publisher.Events(), 1)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("publishes nothing while another relay holds a claim", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("ClaimPendingEvents", "relay 1", now, time.Minute, 100).Return(entities.Events{}, nil)
+
+		publisher := events.NewMemoryPublisher()
+		relay := events.NewRelay(log.NullLogger{}, mockDB, publisher)
+		relay.Claimant = "relay 1"
+
+		published, err := relay.RelayPending(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, published)
+		assert.Empty(t, publisher.Events())
+		mockDB.AssertNotCalled(t, "MarkEventsPublished")
+	})
+
+	t.Run("publishes to every publisher in order", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("ClaimPendingEvents", "relay 1", now, time.Minute, 100).Return(pending, nil)
+		mockDB.On("MarkEventsPublished", []uint64{1}, now).Return(nil)
+
+		first := events.NewMemoryPublisher()
+		second := failingPublisher{MemoryPublisher: events.NewMemoryPublisher(), failID: 2}
+		relay := events.NewRelay(log.NullLogger{}, mockDB, first, second)
+		relay.Claimant = "relay 1"
+
+		// The event the second publisher failed to publish isn't marked as published, although the first
+		// publisher published it
+		published, err := relay.RelayPending(context.Background(), now)
+		assert.Error(t, err)
+		assert.Equal(t, 1, published)
+		assert.Equal(t, pending[:2], first.Events())
+		assert.Equal(t, pending[:1], second.Events())
+		mockDB.AssertExpectations(t)
+	})
+}
+
+func TestPurge(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("DeletePublishedEvents", now.Add(-24*time.Hour)).Return(int64(3), nil)
+
+	relay := events.NewRelay(log.NullLogger{}, mockDB)
+	relay.Retention = 24 * time.Hour
+
+	deleted, err := relay.Purge(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// Tailer publishes the events added to the outbox from the moment it starts, in order, whether they've been
+// relayed or not. Unlike Relay, it doesn't mark events as published: every instance of the service tails the
+// outbox on its own, e.g. to broadcast new articles to its server-sent events clients.
+//
+// Event IDs are allocated before the transactions writing the events commit, so an event can show up after
+// one with a greater ID. A gap in the IDs is waited on for GapTimeout before being skipped, as it can also be
+// left by a transaction that rolled back.
+type Tailer struct {
+	Logger    log.Logger
+	Repo      core.Repository
+	Publisher core.EventPublisher
+	// Tick is how often the outbox is checked for new events.
+	Tick time.Duration
+	// BatchSize is the maximum number of events read from the outbox at once.
+	BatchSize int
+	// GapTimeout is how long a gap in the event IDs is waited on.
+	GapTimeout time.Duration
+
+	// lastID is the ID of the last event published.
+	lastID uint64
+	// gapSince is when the event after lastID was first found missing, or zero if it wasn't.
+	gapSince time.Time
+}
+
+// NewTailer returns a new Tailer.
+func NewTailer(logger log.Logger, repo core.Repository, publisher core.EventPublisher) *Tailer {
+	return &Tailer{
+		Logger:     logger,
+		Repo:       repo,
+		Publisher:  publisher,
+		Tick:       time.Second,
+		BatchSize:  100,
+		GapTimeout: 2 * time.Second,
+	}
+}
+
+// Start sets the tailer to publish the events added from now on.
+func (t *Tailer) Start() error {
+	lastID, err := t.Repo.GetLastEventID()
+	if err != nil {
+		return err
+	}
+
+	t.lastID = lastID
+	return nil
+}
+
+// LastID returns the ID of the last event published or, before any was, of the last event when it started.
+// It's not safe to call while Run is running.
+func (t *Tailer) LastID() uint64 {
+	return t.lastID
+}
+
+// Run publishes the new events every tick, until ctx is done. Start must have been called first.
+func (t *Tailer) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.Tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := t.TailNew(ctx, time.Now()); err != nil {
+			t.Logger.Error(fmt.Sprintf("error tailing events: %s", err.Error()), log.Field("type", "events"))
+		}
+	}
+}
+
+// TailNew publishes the events added after the last one published, in order, until the outbox is exhausted or
+// a gap in the event IDs is found that isn't older than the gap timeout.
+func (t *Tailer) TailNew(ctx context.Context, now time.Time) (published int, err error) {
+	for ctx.Err() == nil {
+		events, err := t.Repo.GetEventsAfter(t.lastID, t.BatchSize)
+		if err != nil {
+			return published, err
+		}
+
+		for _, event := range events {
+			if event.ID != t.lastID+1 {
+				if t.gapSince.IsZero() {
+					t.gapSince = now
+				}
+				if now.Sub(t.gapSince) < t.GapTimeout {
+					return published, nil
+				}
+			}
+
+			if err := t.Publisher.Publish(event); err != nil {
+				return published, err
+			}
+			t.lastID = event.ID
+			t.gapSince = time.Time{}
+			published++
+		}
+
+		if len(events) < t.BatchSize {
+			break
+		}
+	}
+
+	return published, nil
+}
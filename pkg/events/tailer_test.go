@@ -0,0 +1,81 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailNew(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	event := func(id uint64) entities.Event {
+		return entities.Event{ID: id, Type: entities.EventArticleCreated, Article: entities.Article{GUID: "guid"}}
+	}
+
+	t.Run("publishes the events added since it started", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("GetLastEventID").Return(uint64(5), nil)
+		mockDB.On("GetEventsAfter", uint64(5), 2).Return(entities.Events{event(6), event(7)}, nil).Once()
+		mockDB.On("GetEventsAfter", uint64(7), 2).Return(entities.Events{event(8)}, nil).Once()
+		mockDB.On("GetEventsAfter", uint64(8), 2).Return(entities.Events{}, nil).Once()
+
+		publisher := events.NewMemoryPublisher()
+		tailer := events.NewTailer(log.NullLogger{}, mockDB, publisher)
+		tailer.BatchSize = 2
+		require.NoError(t, tailer.Start())
+
+		published, err := tailer.TailNew(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 3, published)
+
+		// Events are never published twice
+		published, err = tailer.TailNew(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 0, published)
+
+		assert.Equal(t, entities.Events{event(6), event(7), event(8)}, publisher.Events())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("waits on gaps until they time out", func(t *testing.T) {
+		mockDB := &mocks.Repository{}
+		mockDB.On("GetLastEventID").Return(uint64(0), nil)
+		mockDB.On("GetEventsAfter", uint64(0), 100).Return(entities.Events{event(1), event(3)}, nil).Once()
+		// Event 2 committed after event 3
+		mockDB.On("GetEventsAfter", uint64(1), 100).Return(entities.Events{event(2), event(3), event(5)}, nil).Once()
+		mockDB.On("GetEventsAfter", uint64(3), 100).Return(entities.Events{event(5)}, nil).Once()
+		// Event 4 never commits
+		mockDB.On("GetEventsAfter", uint64(3), 100).Return(entities.Events{event(5)}, nil).Once()
+
+		publisher := events.NewMemoryPublisher()
+		tailer := events.NewTailer(log.NullLogger{}, mockDB, publisher)
+		require.NoError(t, tailer.Start())
+
+		published, err := tailer.TailNew(context.Background(), now)
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		published, err = tailer.TailNew(context.Background(), now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 2, published)
+
+		published, err = tailer.TailNew(context.Background(), now.Add(time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, 0, published)
+
+		published, err = tailer.TailNew(context.Background(), now.Add(time.Second+tailer.GapTimeout))
+		require.NoError(t, err)
+		assert.Equal(t, 1, published)
+
+		assert.Equal(t, entities.Events{event(1), event(2), event(3), event(5)}, publisher.Events())
+		mockDB.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,178 @@
+// Package feed provides the RSS 2.0, Atom and OPML documents used to publish and ingest articles.
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// Content types of the feed documents.
+const (
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+	AtomContentType = "application/atom+xml; charset=utf-8"
+)
+
+// Metadata holds the feed level information.
+type Metadata struct {
+	Title       string
+	Description string
+	// Link is the URL of the feed itself.
+	Link string
+}
+
+// RSS represents an RSS 2.0 document.
+type RSS struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// RSSChannel represents the channel of an RSS 2.0 document.
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []RSSItem `xml:"item"`
+}
+
+// RSSItem represents an item of an RSS 2.0 channel.
+type RSSItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        RSSGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+// RSSGUID represents the guid of an RSS 2.0 item.
+type RSSGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink string `xml:"isPermaLink,attr,omitempty"`
+}
+
+// Atom represents an Atom document.
+type Atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomEntry represents an entry of an Atom document.
+type AtomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Links      []AtomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Content    string         `xml:"content,omitempty"`
+	Author     *AtomPerson    `xml:"author,omitempty"`
+	Categories []AtomCategory `xml:"category"`
+}
+
+// AtomLink represents a link of an Atom document or entry.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// AtomPerson represents an author of an Atom entry.
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomCategory represents a category of an Atom entry.
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteRSS writes articles as an RSS 2.0 document.
+func WriteRSS(w io.Writer, meta Metadata, articles entities.Articles) error {
+	doc := RSS{
+		Version: "2.0",
+		Channel: RSSChannel{
+			Title:       meta.Title,
+			Link:        meta.Link,
+			Description: meta.Description,
+			Items:       make([]RSSItem, 0, len(articles)),
+		},
+	}
+
+	if latest := latestPublished(articles); !latest.IsZero() {
+		doc.Channel.LastBuildDate = latest.Format(time.RFC1123Z)
+	}
+
+	for _, article := range articles {
+		doc.Channel.Items = append(doc.Channel.Items, RSSItem{
+			Title:       article.Title,
+			Link:        article.Link,
+			Description: article.Description,
+			GUID:        RSSGUID{Value: article.GUID, IsPermaLink: "false"},
+			PubDate:     article.PublishedTime.UTC().Format(time.RFC1123Z),
+			Categories:  []string{article.Category},
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// WriteAtom writes articles as an Atom document.
+func WriteAtom(w io.Writer, meta Metadata, articles entities.Articles) error {
+	updated := latestPublished(articles)
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	doc := Atom{
+		ID:      meta.Link,
+		Title:   meta.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links:   []AtomLink{{Href: meta.Link, Rel: "self"}},
+		Entries: make([]AtomEntry, 0, len(articles)),
+	}
+
+	for _, article := range articles {
+		published := article.PublishedTime.UTC().Format(time.RFC3339)
+		doc.Entries = append(doc.Entries, AtomEntry{
+			ID:         article.GUID,
+			Title:      article.Title,
+			Updated:    published,
+			Published:  published,
+			Links:      []AtomLink{{Href: article.Link, Rel: "alternate"}},
+			Summary:    article.Description,
+			Author:     &AtomPerson{Name: article.Provider},
+			Categories: []AtomCategory{{Term: article.Category}},
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+// writeXML writes the XML header followed by the document.
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// latestPublished returns the most recent published time of all articles.
+func latestPublished(articles entities.Articles) (latest time.Time) {
+	for _, article := range articles {
+		if article.PublishedTime.After(latest) {
+			latest = article.PublishedTime
+		}
+	}
+	return latest
+}
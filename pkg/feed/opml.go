@@ -0,0 +1,127 @@
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// OPMLContentType is the content type of OPML documents.
+const OPMLContentType = "text/x-opml; charset=utf-8"
+
+// OPML represents an OPML 2.0 document.
+type OPML struct {
+	XMLName xml.Name  `xml:"opml"`
+	Version string    `xml:"version,attr"`
+	Head    OPMLHead  `xml:"head"`
+	Body    []Outline `xml:"body>outline"`
+}
+
+// OPMLHead represents the head of an OPML document.
+type OPMLHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+// Outline represents an outline of an OPML document.
+// Outlines with an xmlUrl are feeds, the others group their children.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// OPMLFeed is a feed found in an OPML document.
+type OPMLFeed struct {
+	URL string
+	// Title is the title of the feed outline (or its text when it has no title).
+	Title string
+	// Category is the first category of the feed outline's category attribute or, when it has none, the
+	// text of the closest outline containing it.
+	Category string
+}
+
+// ParseOPML parses an OPML document and returns the feeds it contains, in document order.
+func ParseOPML(r io.Reader) ([]OPMLFeed, error) {
+	var doc OPML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing OPML document: %w", err)
+	}
+
+	var feeds []OPMLFeed
+	var walk func(outlines []Outline, parent string)
+	walk = func(outlines []Outline, parent string) {
+		for _, outline := range outlines {
+			if outline.XMLURL == "" {
+				walk(outline.Outlines, strings.TrimSpace(outline.Text))
+				continue
+			}
+
+			f := OPMLFeed{
+				URL:      strings.TrimSpace(outline.XMLURL),
+				Title:    strings.TrimSpace(outline.Title),
+				Category: opmlCategory(outline.Category),
+			}
+			if f.Title == "" {
+				f.Title = strings.TrimSpace(outline.Text)
+			}
+			if f.Category == "" {
+				f.Category = parent
+			}
+
+			feeds = append(feeds, f)
+		}
+	}
+	walk(doc.Body, "")
+
+	return feeds, nil
+}
+
+// opmlCategory returns the first category of an OPML category attribute, which is a comma separated list of
+// slash delimited category paths (e.g. "/Tech/Gadgets,/News"). The last element of the path is used.
+func opmlCategory(attr string) string {
+	first := strings.TrimSpace(strings.Split(attr, ",")[0])
+	parts := strings.Split(strings.Trim(first, "/"), "/")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+// WriteOPML writes feeds as an OPML 2.0 document with one outline per category holding its feeds.
+func WriteOPML(w io.Writer, title string, feeds entities.Feeds) error {
+	doc := OPML{
+		Version: "2.0",
+		Head:    OPMLHead{Title: title, DateCreated: time.Now().UTC().Format(time.RFC1123Z)},
+		Body:    []Outline{},
+	}
+
+	byCategory := make(map[string][]Outline)
+	for _, f := range feeds {
+		byCategory[f.Category] = append(byCategory[f.Category], Outline{
+			Text:     f.Provider,
+			Title:    f.Provider,
+			Type:     "rss",
+			XMLURL:   f.URL,
+			Category: f.Category,
+		})
+	}
+
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	for _, category := range categories {
+		doc.Body = append(doc.Body, Outline{Text: category, Title: category, Outlines: byCategory[category]})
+	}
+
+	return writeXML(w, doc)
+}
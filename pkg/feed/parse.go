@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Item is a feed item, regardless of the feed format.
+type Item struct {
+	GUID        string
+	Title       string
+	Description string
+	Link        string
+	Published   time.Time
+}
+
+// rssDateLayouts are the date layouts found in the wild for RSS pubDate elements.
+var rssDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+}
+
+// Parse parses an RSS 2.0 or Atom document and returns its items.
+// Items without a GUID use their link as GUID.
+func Parse(r io.Reader) ([]Item, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	default:
+		return nil, fmt.Errorf("unsupported feed format: root element <%s>", root)
+	}
+}
+
+// rootElement returns the name of the root element of an XML document.
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("error parsing feed: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// parseRSS parses an RSS 2.0 document.
+func parseRSS(data []byte) ([]Item, error) {
+	var doc RSS
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing RSS feed: %w", err)
+	}
+
+	items := make([]Item, 0, len(doc.Channel.Items))
+	for _, rssItem := range doc.Channel.Items {
+		item := Item{
+			GUID:        strings.TrimSpace(rssItem.GUID.Value),
+			Title:       strings.TrimSpace(rssItem.Title),
+			Description: strings.TrimSpace(rssItem.Description),
+			Link:        strings.TrimSpace(rssItem.Link),
+			Published:   parseDate(rssItem.PubDate, rssDateLayouts),
+		}
+
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// parseAtom parses an Atom document.
+func parseAtom(data []byte) ([]Item, error) {
+	var doc Atom
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing Atom feed: %w", err)
+	}
+
+	items := make([]Item, 0, len(doc.Entries))
+	for _, entry := range doc.Entries {
+		item := Item{
+			GUID:        strings.TrimSpace(entry.ID),
+			Title:       strings.TrimSpace(entry.Title),
+			Description: strings.TrimSpace(entry.Summary),
+			Published:   parseDate(entry.Published, []string{time.RFC3339}),
+		}
+
+		if item.Description == "" {
+			item.Description = strings.TrimSpace(entry.Content)
+		}
+
+		if item.Published.IsZero() {
+			item.Published = parseDate(entry.Updated, []string{time.RFC3339})
+		}
+
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				item.Link = strings.TrimSpace(link.Href)
+				break
+			}
+		}
+
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// parseDate parses a date trying each layout in turn.
+// It returns the zero time if none of them matches.
+func parseDate(value string, layouts []string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Time{}
+}
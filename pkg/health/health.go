@@ -0,0 +1,207 @@
+// Package health reports the health of the service for liveness and readiness probes.
+package health
+
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of the service and its dependencies.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// DefaultTimeout is the time dependencies have to answer their checks.
+const DefaultTimeout = 2 * time.Second
+
+// Dependency is something the service needs to serve requests, like its database.
+type Dependency struct {
+	Name string
+	// Check returns an error if the dependency is unavailable.
+	Check func() error
+	// Details returns extra information about the dependency (e.g. connection pool statistics), if set.
+	Details func() interface{}
+}
+
+// DependencyStatus is the result of the last check of a dependency.
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	// Error is the error of the last check, if it failed.
+	Error string `json:"error,omitempty"`
+	// LastError and LastErrorAt are the last error a check of the dependency returned, if any did.
+	LastError   string      `json:"last_error,omitempty"`
+	LastErrorAt *time.Time  `json:"last_error_at,omitempty"`
+	CheckedAt   time.Time   `json:"checked_at"`
+	Details     interface{} `json:"details,omitempty"`
+}
+
+// Report is the health of the service, as returned by its probes.
+type Report struct {
+	Status        string             `json:"status"`
+	ShuttingDown  bool               `json:"shutting_down,omitempty"`
+	UptimeSeconds float64            `json:"uptime_seconds"`
+	Dependencies  []DependencyStatus `json:"dependencies"`
+}
+
+// Checker checks the dependencies of the service.
+//
+// The service is live as long as it's able to answer, and ready when all of its dependencies are up and it's
+// not shutting down.
+type Checker struct {
+	Timeout time.Duration
+
+	dependencies []Dependency
+	startedAt    time.Time
+	draining     int32
+
+	mu sync.Mutex
+	// statuses holds the result of the last check of each dependency, by name.
+	statuses map[string]DependencyStatus
+}
+
+// NewChecker returns a new Checker of the given dependencies.
+func NewChecker(dependencies ...Dependency) *Checker {
+	return &Checker{
+		Timeout:      DefaultTimeout,
+		dependencies: dependencies,
+		startedAt:    time.Now(),
+		statuses:     make(map[string]DependencyStatus),
+	}
+}
+
+// Drain makes the service not ready from now on, so that load balancers stop sending it requests ahead of its
+// shutdown.
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+// Live returns the health of the service for liveness probes, which is always up. Dependencies aren't checked,
+// their statuses are the ones of their last check.
+func (c *Checker) Live() Report {
+	report := c.report(StatusUp)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, dependency := range c.dependencies {
+		if status, ok := c.statuses[dependency.Name]; ok {
+			report.Dependencies = append(report.Dependencies, status)
+		}
+	}
+
+	return report
+}
+
+// Ready checks the dependencies (concurrently) and returns the health of the service for readiness probes,
+// which is down if any dependency is, or the service is shutting down.
+func (c *Checker) Ready() Report {
+	statuses := make([]DependencyStatus, len(c.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range c.dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			statuses[i] = c.check(dependency)
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	report := c.report(StatusUp)
+	if report.ShuttingDown {
+		report.Status = StatusDown
+	}
+	for _, status := range statuses {
+		if status.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	report.Dependencies = statuses
+
+	return report
+}
+
+// report returns a report with the given status and no dependencies.
+func (c *Checker) report(status string) Report {
+	return Report{
+		Status:        status,
+		ShuttingDown:  atomic.LoadInt32(&c.draining) == 1,
+		UptimeSeconds: time.Since(c.startedAt).Seconds(),
+		Dependencies:  []DependencyStatus{},
+	}
+}
+
+// check checks a dependency and records its status.
+func (c *Checker) check(dependency Dependency) DependencyStatus {
+	start := time.Now()
+	err := c.checkWithTimeout(dependency)
+	status := DependencyStatus{
+		Name:      dependency.Name,
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start.UTC(),
+	}
+	if dependency.Details != nil {
+		status.Details = dependency.Details()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The last error is kept once the dependency is back up
+	previous := c.statuses[dependency.Name]
+	status.LastError, status.LastErrorAt = previous.LastError, previous.LastErrorAt
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+		status.LastError, status.LastErrorAt = status.Error, &status.CheckedAt
+	}
+
+	c.statuses[dependency.Name] = status
+	return status
+}
+
+// checkWithTimeout runs the check of a dependency, giving up on it after the checker's timeout.
+func (c *Checker) checkWithTimeout(dependency Dependency) error {
+	// Buffered, so that the check can still return once given up on
+	result := make(chan error, 1)
+	go func() {
+		result <- dependency.Check()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(c.Timeout):
+		return fmt.Errorf("timed out after %s", c.Timeout)
+	}
+}
+
+// PoolStats are the statistics of a database connection pool.
+type PoolStats struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitDurationMS     float64 `json:"wait_duration_ms"`
+}
+
+// DBStatsDetails returns a Details function of a database dependency reporting its connection pool statistics.
+func DBStatsDetails(stats func() sql.DBStats) func() interface{} {
+	return func() interface{} {
+		s := stats()
+		return PoolStats{
+			MaxOpenConnections: s.MaxOpenConnections,
+			OpenConnections:    s.OpenConnections,
+			InUse:              s.InUse,
+			Idle:               s.Idle,
+			WaitCount:          s.WaitCount,
+			WaitDurationMS:     float64(s.WaitDuration.Microseconds()) / 1000,
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package health_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	var dbErr error
+	checker := health.NewChecker(
+		health.Dependency{Name: "database", Check: func() error { return dbErr },
+			Details: func() interface{} { return health.PoolStats{OpenConnections: 2} }},
+		health.Dependency{Name: "slow", Check: func() error { time.Sleep(time.Second); return nil }})
+	checker.Timeout = 50 * time.Millisecond
+
+	// Nothing was checked yet
+	live := checker.Live()
+	assert.Equal(t, health.StatusUp, live.Status)
+	assert.Empty(t, live.Dependencies)
+
+	report := checker.Ready()
+	assert.Equal(t, health.StatusDown, report.Status)
+	require.Len(t, report.Dependencies, 2)
+	assert.Equal(t, health.StatusUp, report.Dependencies[0].Status)
+	assert.Equal(t, health.PoolStats{OpenConnections: 2}, report.Dependencies[0].Details)
+	assert.Equal(t, health.StatusDown, report.Dependencies[1].Status)
+	assert.Contains(t, report.Dependencies[1].Error, "timed out")
+
+	checker = health.NewChecker(health.Dependency{Name: "database", Check: func() error { return dbErr }})
+	assert.Equal(t, health.StatusUp, checker.Ready().Status)
+
+	dbErr = errors.New("connection refused")
+	report = checker.Ready()
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, "connection refused", report.Dependencies[0].Error)
+
+	// Liveness doesn't depend on the dependencies, but reports their last status
+	live = checker.Live()
+	assert.Equal(t, health.StatusUp, live.Status)
+	require.Len(t, live.Dependencies, 1)
+	assert.Equal(t, health.StatusDown, live.Dependencies[0].Status)
+
+	// The last error is kept once the dependency is back up
+	dbErr = nil
+	report = checker.Ready()
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Empty(t, report.Dependencies[0].Error)
+	assert.Equal(t, "connection refused", report.Dependencies[0].LastError)
+	assert.NotNil(t, report.Dependencies[0].LastErrorAt)
+
+	// Not ready once draining
+	checker.Drain()
+	report = checker.Ready()
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.True(t, report.ShuttingDown)
+	assert.Equal(t, health.StatusUp, checker.Live().Status)
+}
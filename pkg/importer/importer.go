@@ -0,0 +1,226 @@
+// Package importer imports articles in bulk from NDJSON or JSON array documents into the repository.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// DefaultBatchSize is the default number of articles written to the repository at once.
+const DefaultBatchSize = 500
+
+// maxLineSize is the maximum size of a single NDJSON line.
+const maxLineSize = 10 << 20
+
+// Entry identifies a record that was not imported.
+type Entry struct {
+	Source string `json:"source"`
+	// Record is the line number for NDJSON documents or the (1-based) index for JSON arrays.
+	Record int    `json:"record"`
+	GUID   string `json:"guid,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report summarises an import.
+type Report struct {
+	Read       int     `json:"read"`
+	Inserted   int     `json:"inserted"`
+	Duplicates []Entry `json:"duplicates"`
+	Rejected   []Entry `json:"rejected"`
+}
+
+// Importer reads articles from documents and writes them to the repository in batches.
+type Importer struct {
+	Logger    log.Logger
+	Repo      core.Repository
+	BatchSize int
+
+	report Report
+	batch  entities.Articles
+	// batchEntries keeps track of where each article in the current batch came from.
+	batchEntries map[string]Entry
+}
+
+// NewImporter returns a new Importer.
+func NewImporter(logger log.Logger, repo core.Repository, batchSize int) *Importer {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Importer{
+		Logger:       logger,
+		Repo:         repo,
+		BatchSize:    batchSize,
+		report:       Report{Duplicates: []Entry{}, Rejected: []Entry{}},
+		batchEntries: make(map[string]Entry),
+	}
+}
+
+// Import imports all articles in r, which can either be a JSON array or newline-delimited JSON.
+// Source names the document in the report.
+// Invalid records are rejected and reported, only I/O, malformed JSON arrays and repository errors stop
+// the import.
+func (i *Importer) Import(source string, r io.Reader) error {
+	br := bufio.NewReader(r)
+
+	isArray, err := startsWithArray(br)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", source, err)
+	}
+
+	if isArray {
+		err = i.importArray(source, br)
+	} else {
+		err = i.importNDJSON(source, br)
+	}
+	if err != nil {
+		return err
+	}
+
+	return i.flush()
+}
+
+// Report returns the report of everything imported so far.
+// Articles still waiting to be written are not accounted for until Import returns.
+func (i *Importer) Report() Report {
+	return i.report
+}
+
+// importArray imports a JSON array document.
+func (i *Importer) importArray(source string, r io.Reader) error {
+	decoder := json.NewDecoder(r)
+
+	// Opening bracket
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("error reading %s: %w", source, err)
+	}
+
+	for record := 1; decoder.More(); record++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("error reading %s at record %d: %w", source, record, err)
+		}
+
+		if err := i.add(source, record, raw); err != nil {
+			return err
+		}
+	}
+
+	// Closing bracket
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("error reading %s: %w", source, err)
+	}
+
+	return nil
+}
+
+// importNDJSON imports a newline-delimited JSON document.
+func (i *Importer) importNDJSON(source string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+
+		if err := i.add(source, line, raw); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading %s: %w", source, err)
+	}
+
+	return nil
+}
+
+// add validates a single record and queues it for writing, flushing the batch when full.
+func (i *Importer) add(source string, record int, raw []byte) error {
+	i.report.Read++
+
+	var body entities.ArticleInput
+	if err := json.Unmarshal(raw, &body); err != nil {
+		i.report.Rejected = append(i.report.Rejected, Entry{Source: source, Record: record, Error: err.Error()})
+		return nil
+	}
+
+	if err := body.Validate(); err != nil {
+		i.report.Rejected = append(i.report.Rejected,
+			Entry{Source: source, Record: record, GUID: body.GUID, Error: err.Error()})
+		return nil
+	}
+
+	// Duplicates within the same batch are reported here, the repository only reports the ones it has stored.
+	if _, ok := i.batchEntries[body.GUID]; ok {
+		i.report.Duplicates = append(i.report.Duplicates, Entry{Source: source, Record: record, GUID: body.GUID})
+		return nil
+	}
+
+	i.batch = append(i.batch, body.Article())
+	i.batchEntries[body.GUID] = Entry{Source: source, Record: record, GUID: body.GUID}
+
+	if len(i.batch) >= i.BatchSize {
+		return i.flush()
+	}
+
+	return nil
+}
+
+// flush writes the current batch to the repository.
+func (i *Importer) flush() error {
+	if len(i.batch) == 0 {
+		return nil
+	}
+
+	duplicates, err := i.Repo.AddArticles(i.batch)
+	if err != nil {
+		return fmt.Errorf("error writing articles: %w", err)
+	}
+
+	for _, guid := range duplicates {
+		i.report.Duplicates = append(i.report.Duplicates, i.batchEntries[guid])
+	}
+	i.report.Inserted += len(i.batch) - len(duplicates)
+
+	i.Logger.Info(fmt.Sprintf("imported batch of %d articles", len(i.batch)),
+		log.Field("type", "import"),
+		log.Fields(map[string]interface{}{
+			"read":       i.report.Read,
+			"inserted":   i.report.Inserted,
+			"duplicates": len(i.report.Duplicates),
+			"rejected":   len(i.report.Rejected),
+		}))
+
+	i.batch = nil
+	i.batchEntries = make(map[string]Entry)
+	return nil
+}
+
+// startsWithArray reports whether the first non-whitespace character in r opens a JSON array.
+func startsWithArray(r *bufio.Reader) (bool, error) {
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+
+		return b == '[', r.UnreadByte()
+	}
+}
@@ -0,0 +1,77 @@
+package importer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const validRecord = `{"guid": "%s", "title": "title", "description": "description", "link": "link",
+"published_date": "2020-05-10T12:30:00Z", "provider": "provider", "category": "category"}`
+
+func record(guid string) string {
+	return strings.ReplaceAll(strings.Replace(validRecord, "%s", guid, 1), "\n", " ")
+}
+
+func TestImport(t *testing.T) {
+	tests := map[string]struct {
+		input              string
+		expectedRead       int
+		expectedInserted   int
+		expectedDuplicates []importer.Entry
+		expectedRejected   int
+	}{
+		"ndjson": {
+			input: record("guid 1") + "\n\n" + record("stored") + "\n" +
+				`{"guid": "guid 3"}` + "\n" + "not json\n" + record("guid 1") + "\n",
+			expectedRead:     5,
+			expectedInserted: 1,
+			expectedDuplicates: []importer.Entry{
+				{Source: "test", Record: 3, GUID: "stored"},
+				{Source: "test", Record: 6, GUID: "guid 1"},
+			},
+			expectedRejected: 2,
+		},
+		"json array": {
+			input:              " [" + record("guid 1") + ", " + record("guid 2") + ", " + record("guid 3") + "]",
+			expectedRead:       3,
+			expectedInserted:   3,
+			expectedDuplicates: []importer.Entry{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			stored := map[string]bool{"stored": true}
+
+			mockDB := &mocks.Repository{}
+			mockDB.On("AddArticles", mock.Anything).Return(func(articles entities.Articles) []string {
+				var duplicates []string
+				for _, article := range articles {
+					if stored[article.GUID] {
+						duplicates = append(duplicates, article.GUID)
+					}
+					stored[article.GUID] = true
+				}
+				return duplicates
+			}, nil)
+
+			imp := importer.NewImporter(log.NullLogger{}, mockDB, 2)
+			err := imp.Import("test", strings.NewReader(test.input))
+			require.NoError(t, err)
+
+			report := imp.Report()
+			assert.Equal(t, test.expectedRead, report.Read)
+			assert.Equal(t, test.expectedInserted, report.Inserted)
+			assert.Equal(t, test.expectedDuplicates, report.Duplicates)
+			assert.Len(t, report.Rejected, test.expectedRejected)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+// Package ingestion periodically fetches the registered RSS/Atom feeds and stores their items as articles.
+package ingestion
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/feed"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+)
+
+// maxFeedSize is the maximum size of a feed document.
+const maxFeedSize = 10 << 20
+
+// Result holds the outcome of polling a feed.
+type Result struct {
+	// NotModified is set when the feed hasn't changed since it was last fetched.
+	NotModified bool
+	Fetched     int
+	Created     int
+	Duplicates  int
+	Rejected    int
+	// ETag and LastModified are the validators to send on the next (conditional) fetch.
+	ETag         string
+	LastModified string
+}
+
+// HTTPStatusError is returned when a feed responds with an unexpected HTTP status.
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is the delay requested by the server through the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %s", e.Status)
+}
+
+// Poller polls the feeds registered in the repository and adds their items to the repository.
+type Poller struct {
+	Logger log.Logger
+	Repo   core.Repository
+	Client *http.Client
+	// DefaultInterval is the poll interval of feeds that don't set their own.
+	DefaultInterval time.Duration
+	// MaxBackoff caps the delay between retries of failing feeds.
+	MaxBackoff time.Duration
+	// MaxFailures is the number of consecutive failures after which a feed is disabled (0 means never).
+	MaxFailures int
+	// Tick is how often the registry is checked for feeds due to be polled.
+	Tick time.Duration
+	// Metrics records the articles created from feed items, if set.
+	Metrics *metrics.Metrics
+
+	// random returns a number in [0.0,1.0) used to add jitter to the backoff.
+	random func() float64
+}
+
+// NewPoller returns a new Poller.
+func NewPoller(logger log.Logger, repo core.Repository, defaultInterval time.Duration, maxBackoff time.Duration,
+	maxFailures int) *Poller {
+	return &Poller{
+		Logger:          logger,
+		Repo:            repo,
+		Client:          &http.Client{Timeout: 30 * time.Second},
+		DefaultInterval: defaultInterval,
+		MaxBackoff:      maxBackoff,
+		MaxFailures:     maxFailures,
+		Tick:            time.Minute,
+		random:          rand.Float64,
+	}
+}
+
+// Run polls the feeds that are due straight away and then every tick, until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Tick)
+	defer ticker.Stop()
+
+	for {
+		p.PollDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollDue polls the enabled feeds whose poll interval has elapsed since they were last fetched, recording
+// the outcome of each one in the repository.
+func (p *Poller) PollDue(ctx context.Context, now time.Time) {
+	feeds, err := p.Repo.GetFeeds()
+	if err != nil {
+		p.Logger.Error(fmt.Sprintf("error getting feeds: %s", err.Error()), log.Field("type", "ingestion"))
+		return
+	}
+
+	for _, feed := range feeds {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !feed.Enabled || !p.due(feed, now) {
+			continue
+		}
+
+		result, err := p.Poll(ctx, feed)
+		if ctx.Err() != nil {
+			// Don't record fetches cut short by shutdown
+			return
+		}
+
+		fetch := entities.FeedFetch{FetchedAt: now, ETag: result.ETag, LastModified: result.LastModified}
+
+		if err != nil {
+			fetch.Error = err.Error()
+			fetch.ETag, fetch.LastModified = feed.ETag, feed.LastModified
+			fetch.ConsecutiveFailures = feed.ConsecutiveFailures + 1
+
+			delay := p.backoff(fetch.ConsecutiveFailures, p.interval(feed))
+			if errT, ok := err.(*HTTPStatusError); ok && errT.RetryAfter > delay {
+				delay = errT.RetryAfter
+			}
+			nextFetchAt := now.Add(delay)
+			fetch.NextFetchAt = &nextFetchAt
+
+			fetch.Disable = p.MaxFailures > 0 && fetch.ConsecutiveFailures >= p.MaxFailures
+
+			p.Logger.Error(fmt.Sprintf("error polling feed: %s", fetch.Error),
+				log.Field("type", "ingestion"),
+				log.Fields(map[string]interface{}{
+					"url":           feed.URL,
+					"failures":      fetch.ConsecutiveFailures,
+					"next_fetch_at": nextFetchAt,
+					"disabled":      fetch.Disable,
+				}))
+		} else {
+			p.Logger.Info("feed polled",
+				log.Field("type", "ingestion"),
+				log.Fields(map[string]interface{}{
+					"url":          feed.URL,
+					"not_modified": result.NotModified,
+					"fetched":      result.Fetched,
+					"created":      result.Created,
+					"duplicates":   result.Duplicates,
+					"rejected":     result.Rejected,
+				}))
+		}
+
+		if err := p.Repo.UpdateFeedStatus(feed.ID, fetch); err != nil {
+			p.Logger.Error(fmt.Sprintf("error updating feed status: %s", err.Error()),
+				log.Field("type", "ingestion"), log.Field("url", feed.URL))
+		}
+	}
+}
+
+// due reports whether a feed is due to be polled.
+func (p *Poller) due(feed entities.Feed, now time.Time) bool {
+	if feed.NextFetchAt != nil {
+		return !now.Before(*feed.NextFetchAt)
+	}
+
+	if feed.LastFetchedAt == nil {
+		return true
+	}
+
+	return !now.Before(feed.LastFetchedAt.Add(p.interval(feed)))
+}
+
+// interval returns the poll interval of a feed.
+func (p *Poller) interval(feed entities.Feed) time.Duration {
+	if feed.PollIntervalSeconds > 0 {
+		return time.Duration(feed.PollIntervalSeconds) * time.Second
+	}
+	return p.DefaultInterval
+}
+
+// backoff returns the delay before retrying a feed after a number of consecutive failures.
+// The delay doubles with each failure, starting at the poll interval and capped at MaxBackoff, and is
+// randomised within its upper half so that failing feeds don't retry in lockstep.
+func (p *Poller) backoff(failures int, interval time.Duration) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	return delay/2 + time.Duration(p.random()*float64(delay/2))
+}
+
+// Poll fetches a feed once and adds its items to the repository.
+// The fetch is conditional on the feed's ETag and Last-Modified validators, if it has any.
+// Items are validated with the same rules as articles added through the API, invalid ones are rejected.
+func (p *Poller) Poll(ctx context.Context, source entities.Feed) (result Result, err error) {
+	result.ETag, result.LastModified = source.ETag, source.LastModified
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source.URL, nil)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, */*;q=0.8")
+	req.Header.Set("User-Agent", "news-app-articles-mgmt-service")
+	if source.ETag != "" {
+		req.Header.Set("If-None-Match", source.ETag)
+	}
+	if source.LastModified != "" {
+		req.Header.Set("If-Modified-Since", source.LastModified)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 304 {
+		result.NotModified = true
+		return result, nil
+	} else if resp.StatusCode != 200 {
+		return result, &HTTPStatusError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	items, err := feed.Parse(io.LimitReader(resp.Body, maxFeedSize))
+	if err != nil {
+		return result, err
+	}
+
+	result.Fetched = len(items)
+
+	for _, item := range items {
+		body := entities.ArticleInput{
+			GUID:          item.GUID,
+			Title:         item.Title,
+			Description:   item.Description,
+			Link:          item.Link,
+			PublishedTime: item.Published,
+			Provider:      source.Provider,
+			Category:      source.Category,
+		}
+
+		if err := body.Validate(); err != nil {
+			p.Logger.Debug(fmt.Sprintf("rejected feed item: %s", err.Error()),
+				log.Field("type", "ingestion"), log.Field("url", source.URL), log.Field("guid", item.GUID))
+			result.Rejected++
+			p.Metrics.ArticleIngested(metrics.SourceFeed, metrics.ResultRejected)
+			continue
+		}
+
+		err = p.Repo.AddArticle(body.Article())
+		if _, ok := err.(*repository.DBDUPError); ok {
+			result.Duplicates++
+			p.Metrics.ArticleIngested(metrics.SourceFeed, metrics.ResultDuplicate)
+			continue
+		} else if err != nil {
+			return result, err
+		}
+
+		result.Created++
+		p.Metrics.ArticleIngested(metrics.SourceFeed, metrics.ResultCreated)
+	}
+
+	// Validators are only kept once the document has been processed, so that a failure is retried in full
+	result.ETag = resp.Header.Get("ETag")
+	result.LastModified = resp.Header.Get("Last-Modified")
+
+	return result, nil
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date.
+// It returns 0 if the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
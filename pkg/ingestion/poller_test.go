@@ -0,0 +1,222 @@
+package ingestion_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/ingestion"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const rssDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Provider</title>
+    <link>http://provider.example</link>
+    <description>Provider news</description>
+    <item>
+      <title>title 1</title>
+      <link>http://provider.example/1</link>
+      <description>description 1</description>
+      <guid isPermaLink="false">guid 1</guid>
+      <pubDate>Sun, 10 May 2020 12:30:00 +0000</pubDate>
+    </item>
+    <item>
+      <title>title 2</title>
+      <link>http://provider.example/2</link>
+      <description>description 2</description>
+      <pubDate>Sat, 10 Oct 2020 12:30:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>no date</title>
+      <link>http://provider.example/3</link>
+      <description>description 3</description>
+    </item>
+  </channel>
+</rss>`
+
+const atomDoc = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Provider</title>
+  <id>urn:provider</id>
+  <updated>2020-05-10T12:30:00Z</updated>
+  <entry>
+    <id>urn:duplicate</id>
+    <title>title 1</title>
+    <link rel="alternate" href="http://provider.example/1"/>
+    <summary>description 1</summary>
+    <updated>2020-05-10T12:30:00Z</updated>
+  </entry>
+  <entry>
+    <id>urn:entry-2</id>
+    <title>title 2</title>
+    <link href="http://provider.example/2"/>
+    <content>description 2</content>
+    <published>2020-01-12T10:00:00+01:00</published>
+    <updated>2020-01-13T10:00:00Z</updated>
+  </entry>
+</feed>`
+
+func TestPoll(t *testing.T) {
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			w.Write([]byte(rssDoc))
+		case "/atom":
+			w.Write([]byte(atomDoc))
+		case "/conditional":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(304)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", "Sun, 10 May 2020 12:30:00 GMT")
+			w.Write([]byte(atomDoc))
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer feedServer.Close()
+
+	tests := map[string]struct {
+		path             string
+		etag             string
+		expectedErr      bool
+		expectedResult   ingestion.Result
+		expectedArticles []entities.Article
+	}{
+		"rss": {
+			path:           "/rss",
+			expectedResult: ingestion.Result{Fetched: 3, Created: 2, Rejected: 1},
+			expectedArticles: []entities.Article{
+				{GUID: "guid 1", Title: "title 1", Description: "description 1", Link: "http://provider.example/1",
+					PublishedTime: time.Date(2020, 5, 10, 12, 30, 0, 0, time.UTC), Provider: "provider", Category: "category"},
+				{GUID: "http://provider.example/2", Title: "title 2", Description: "description 2", Link: "http://provider.example/2",
+					PublishedTime: time.Date(2020, 10, 10, 12, 30, 0, 0, time.UTC), Provider: "provider", Category: "category"},
+			},
+		},
+		"atom": {
+			path:           "/atom",
+			expectedResult: ingestion.Result{Fetched: 2, Created: 1, Duplicates: 1},
+			expectedArticles: []entities.Article{
+				{GUID: "urn:entry-2", Title: "title 2", Description: "description 2", Link: "http://provider.example/2",
+					PublishedTime: time.Date(2020, 1, 12, 9, 0, 0, 0, time.UTC), Provider: "provider", Category: "category"},
+			},
+		},
+		"conditional modified": {
+			path: "/conditional",
+			expectedResult: ingestion.Result{Fetched: 2, Created: 1, Duplicates: 1, ETag: `"v1"`,
+				LastModified: "Sun, 10 May 2020 12:30:00 GMT"},
+			expectedArticles: []entities.Article{
+				{GUID: "urn:entry-2", Title: "title 2", Description: "description 2", Link: "http://provider.example/2",
+					PublishedTime: time.Date(2020, 1, 12, 9, 0, 0, 0, time.UTC), Provider: "provider", Category: "category"},
+			},
+		},
+		"conditional not modified": {
+			path:           "/conditional",
+			etag:           `"v1"`,
+			expectedResult: ingestion.Result{NotModified: true, ETag: `"v1"`},
+		},
+		"not found": {
+			path:        "/missing",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var added []entities.Article
+
+			mockDB := &mocks.Repository{}
+			mockDB.On("AddArticle", mock.Anything).Return(func(article entities.Article) error {
+				if article.GUID == "urn:duplicate" {
+					return &repository.DBDUPError{}
+				}
+				added = append(added, article)
+				return nil
+			})
+
+			poller := ingestion.NewPoller(log.NullLogger{}, mockDB, time.Minute, time.Hour, 3)
+			source := entities.Feed{URL: feedServer.URL + test.path, Provider: "provider", Category: "category",
+				ETag: test.etag}
+
+			result, err := poller.Poll(context.Background(), source)
+			if test.expectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResult, result)
+			assert.Equal(t, test.expectedArticles, added)
+		})
+	}
+}
+
+func TestPollDue(t *testing.T) {
+	feedServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			w.Write([]byte(rssDoc))
+		case "/busy":
+			w.Header().Set("Retry-After", "7200")
+			w.WriteHeader(503)
+		default:
+			w.WriteHeader(500)
+		}
+	}))
+	defer feedServer.Close()
+
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-5 * time.Minute)
+	old := now.Add(-time.Hour)
+	later := now.Add(time.Minute)
+
+	feeds := entities.Feeds{
+		{ID: 1, URL: feedServer.URL + "/rss", Provider: "p", Category: "c", Enabled: true},
+		{ID: 2, URL: feedServer.URL + "/rss", Provider: "p", Category: "c", Enabled: false},
+		{ID: 3, URL: feedServer.URL + "/rss", Provider: "p", Category: "c", Enabled: true, LastFetchedAt: &recent},
+		{ID: 4, URL: feedServer.URL + "/rss", Provider: "p", Category: "c", Enabled: true, LastFetchedAt: &recent,
+			PollIntervalSeconds: 60},
+		{ID: 5, URL: feedServer.URL + "/busy", Provider: "p", Category: "c", Enabled: true, LastFetchedAt: &old,
+			ConsecutiveFailures: 2, ETag: `"v1"`},
+		{ID: 6, URL: feedServer.URL + "/broken", Provider: "p", Category: "c", Enabled: true, LastFetchedAt: &old},
+		{ID: 7, URL: feedServer.URL + "/broken", Provider: "p", Category: "c", Enabled: true, LastFetchedAt: &old,
+			NextFetchAt: &later},
+	}
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetFeeds").Return(feeds, nil)
+	mockDB.On("AddArticle", mock.Anything).Return(nil)
+
+	success := entities.FeedFetch{FetchedAt: now}
+	mockDB.On("UpdateFeedStatus", uint64(1), success).Return(nil).Once()
+	mockDB.On("UpdateFeedStatus", uint64(4), success).Return(nil).Once()
+
+	// Retry-After beats the backoff and the third consecutive failure disables the feed
+	retryAt := now.Add(2 * time.Hour)
+	mockDB.On("UpdateFeedStatus", uint64(5), entities.FeedFetch{FetchedAt: now,
+		Error: "unexpected HTTP status: 503 Service Unavailable", ETag: `"v1"`, ConsecutiveFailures: 3,
+		NextFetchAt: &retryAt, Disable: true}).Return(nil).Once()
+
+	// First failure backs off by up to the poll interval
+	mockDB.On("UpdateFeedStatus", uint64(6), mock.MatchedBy(func(fetch entities.FeedFetch) bool {
+		return fetch.ConsecutiveFailures == 1 && !fetch.Disable && fetch.NextFetchAt != nil &&
+			!fetch.NextFetchAt.Before(now.Add(15*time.Minute/2)) && !fetch.NextFetchAt.After(now.Add(15*time.Minute))
+	})).Return(nil).Once()
+
+	poller := ingestion.NewPoller(log.NullLogger{}, mockDB, 15*time.Minute, 24*time.Hour, 3)
+	poller.PollDue(context.Background(), now)
+
+	mockDB.AssertExpectations(t)
+	mockDB.AssertNumberOfCalls(t, "UpdateFeedStatus", 4)
+}
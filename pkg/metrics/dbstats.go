@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dbStat is a connection pool statistic.
+type dbStat struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(stats sql.DBStats) float64
+}
+
+// DBStatsCollector exposes the connection pool statistics of a database, read on every scrape.
+type DBStatsCollector struct {
+	Stats func() sql.DBStats
+	stats []dbStat
+}
+
+// NewDBStatsCollector returns a new DBStatsCollector.
+func NewDBStatsCollector(stats func() sql.DBStats) *DBStatsCollector {
+	gauge := func(name string, help string, value func(stats sql.DBStats) float64) dbStat {
+		return dbStat{desc: prometheus.NewDesc(name, help, nil, nil), valueType: prometheus.GaugeValue, value: value}
+	}
+	counter := func(name string, help string, value func(stats sql.DBStats) float64) dbStat {
+		return dbStat{desc: prometheus.NewDesc(name, help, nil, nil), valueType: prometheus.CounterValue, value: value}
+	}
+
+	return &DBStatsCollector{Stats: stats, stats: []dbStat{
+		gauge("db_max_open_connections", "Maximum number of open connections to the database.",
+			func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }),
+		gauge("db_open_connections", "Number of established connections, in use or idle.",
+			func(s sql.DBStats) float64 { return float64(s.OpenConnections) }),
+		gauge("db_in_use_connections", "Number of connections in use.",
+			func(s sql.DBStats) float64 { return float64(s.InUse) }),
+		gauge("db_idle_connections", "Number of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.Idle) }),
+		counter("db_wait_count_total", "Number of connections waited for.",
+			func(s sql.DBStats) float64 { return float64(s.WaitCount) }),
+		counter("db_wait_duration_seconds_total", "Time spent waiting for connections.",
+			func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }),
+		counter("db_max_idle_closed_total", "Number of connections closed due to the maximum of idle connections.",
+			func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }),
+		counter("db_max_lifetime_closed_total", "Number of connections closed due to their maximum lifetime.",
+			func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }),
+	}}
+}
+
+// Describe implements prometheus.Collector.
+func (c *DBStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, stat := range c.stats {
+		ch <- stat.desc
+	}
+}
+
+// Collect implements prometheus.Collector, reading the pool statistics.
+func (c *DBStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.Stats()
+
+	for _, stat := range c.stats {
+		ch <- prometheus.MustNewConstMetric(stat.desc, stat.valueType, stat.value(stats))
+	}
+}
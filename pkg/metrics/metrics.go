@@ -0,0 +1,36 @@
+// Package metrics collects the service metrics and exposes them in the Prometheus text format.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Article ingestion sources and results.
+const (
+	SourceAPI  = "api"
+	SourceFeed = "feed"
+
+	ResultCreated   = "created"
+	ResultDuplicate = "duplicate"
+	ResultRejected  = "rejected"
+)
+
+// Registry holds the collectors exposed together, and serves them.
+type Registry struct {
+	*prometheus.Registry
+	handler http.Handler
+}
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() Registry {
+	registry := prometheus.NewRegistry()
+	return Registry{Registry: registry, handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{})}
+}
+
+// ServeHTTP serves the metrics of every collector.
+func (r Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.handler.ServeHTTP(w, req)
+}
@@ -0,0 +1,83 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeHTTP(t *testing.T) {
+	m := metrics.New()
+	m.ObserveRequest("GET", "/b", 200, 50*time.Millisecond)
+	m.ObserveRequest("GET", `/a"\`, 200, 50*time.Millisecond)
+	m.ObserveRequest("GET", `/a"\`, 200, 5*time.Second)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8; escaping=values", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "# TYPE http_requests_total counter\n")
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/a\"\\",status="200"} 2`)
+	assert.Contains(t, body, `http_requests_total{method="GET",route="/b",status="200"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_bucket{method="GET",route="/b",status="200",le="0.05"} 1`)
+	assert.Contains(t, body, `http_request_duration_seconds_count{method="GET",route="/a\"\\",status="200"} 2`)
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	collector := metrics.NewDBStatsCollector(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 10, OpenConnections: 3, InUse: 2, Idle: 1, WaitCount: 4,
+			WaitDuration: 1500 * time.Millisecond}
+	})
+
+	expected := `# HELP db_in_use_connections Number of connections in use.
+# TYPE db_in_use_connections gauge
+db_in_use_connections 2
+# HELP db_wait_count_total Number of connections waited for.
+# TYPE db_wait_count_total counter
+db_wait_count_total 4
+# HELP db_wait_duration_seconds_total Time spent waiting for connections.
+# TYPE db_wait_duration_seconds_total counter
+db_wait_duration_seconds_total 1.5
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "db_in_use_connections",
+		"db_wait_count_total", "db_wait_duration_seconds_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+}
+
+func TestObserveStatement(t *testing.T) {
+	m := metrics.New()
+
+	m.ObserveStatement("AddArticle", "create", "articles", time.Millisecond, nil)
+	m.ObserveStatement("AddArticle", "create", "articles", time.Millisecond, &repository.DBDUPError{})
+	m.ObserveStatement("GetFeed", "query", "feeds", time.Millisecond, &repository.DBNotFoundError{})
+	m.ObserveStatement("GetFeeds", "query", "feeds", time.Millisecond,
+		&repository.DBServiceError{Msg: "database error", Err: errors.New("gone")})
+
+	assert.Equal(t, 3, testutil.CollectAndCount(m.DBStatementDuration))
+	assert.Equal(t, float64(1),
+		testutil.ToFloat64(m.DBStatementErrors.WithLabelValues("AddArticle", "create", "articles", "duplicate")))
+	assert.Equal(t, float64(1),
+		testutil.ToFloat64(m.DBStatementErrors.WithLabelValues("GetFeed", "query", "feeds", "not_found")))
+	assert.Equal(t, float64(1),
+		testutil.ToFloat64(m.DBStatementErrors.WithLabelValues("GetFeeds", "query", "feeds", "database")))
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *metrics.Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveRequest("GET", "/", 200, time.Second)
+		m.ObserveStatement("GetFeeds", "query", "feeds", time.Second, nil)
+		m.ArticleIngested(metrics.SourceAPI, metrics.ResultCreated)
+	})
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds the metrics of the service.
+//
+// Its methods can be called on a nil *Metrics, which records nothing, so that components don't have to check
+// whether metrics are enabled.
+type Metrics struct {
+	Registry
+
+	HTTPRequests        *prometheus.CounterVec
+	HTTPRequestDuration *prometheus.HistogramVec
+	DBStatementDuration *prometheus.HistogramVec
+	DBStatementErrors   *prometheus.CounterVec
+	ArticlesIngested    *prometheus.CounterVec
+}
+
+// New returns a new Metrics, with its metrics registered.
+func New() *Metrics {
+	m := &Metrics{
+		Registry: NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total",
+			Help: "Number of HTTP requests served."}, []string{"method", "route", "status"}),
+		HTTPRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "http_request_duration_seconds",
+			Help: "Latency of HTTP requests."}, []string{"method", "route", "status"}),
+		DBStatementDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "db_statement_duration_seconds", Help: "Duration of SQL statements, by repository method."},
+			[]string{"method", "operation", "table"}),
+		DBStatementErrors: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "db_statement_errors_total",
+			Help: "Number of SQL statements that failed, by repository method and kind of error."},
+			[]string{"method", "operation", "table", "kind"}),
+		ArticlesIngested: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "articles_ingested_total",
+			Help: "Number of articles received, by source (api or feed) and result (created, duplicate or rejected)."},
+			[]string{"source", "result"}),
+	}
+
+	m.MustRegister(m.HTTPRequests, m.HTTPRequestDuration, m.DBStatementDuration, m.DBStatementErrors,
+		m.ArticlesIngested)
+	return m
+}
+
+// ObserveRequest records a served HTTP request.
+// The route is the route pattern (e.g. '/api/v1/feeds/:id'), not the path, to keep the number of series bounded.
+func (m *Metrics) ObserveRequest(method string, route string, status int, latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	statusCode := strconv.Itoa(status)
+	m.HTTPRequests.WithLabelValues(method, route, statusCode).Inc()
+	m.HTTPRequestDuration.WithLabelValues(method, route, statusCode).Observe(latency.Seconds())
+}
+
+// ObserveStatement records an SQL statement and its error, if any, under the repository method it was run for.
+// It's a repository.StatementObserver.
+func (m *Metrics) ObserveStatement(method string, operation string, table string, duration time.Duration,
+	err error) {
+
+	if m == nil {
+		return
+	}
+
+	m.DBStatementDuration.WithLabelValues(method, operation, table).Observe(duration.Seconds())
+
+	if err != nil {
+		kind := "database"
+		switch err.(type) {
+		case *repository.DBDUPError:
+			kind = "duplicate"
+		case *repository.DBNotFoundError:
+			kind = "not_found"
+		}
+		m.DBStatementErrors.WithLabelValues(method, operation, table, kind).Inc()
+	}
+}
+
+// ArticleIngested records an article received from a source, and whether it was created.
+func (m *Metrics) ArticleIngested(source string, result string) {
+	if m == nil {
+		return
+	}
+
+	m.ArticlesIngested.WithLabelValues(source, result).Inc()
+}
@@ -0,0 +1,129 @@
+// Package secrets provides secret providers, which configuration settings can take their values from.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeySize is the size in bytes of the keys encrypting secrets files (AES-256).
+const KeySize = 32
+
+// ErrSecretNotFound is returned when a provider doesn't have the secret asked for.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// FileProvider reads secrets from a local file holding a JSON object of secrets by name, encrypted with
+// AES-256-GCM (see Encrypt). The file is read on every lookup, so that rotated secrets are picked up.
+type FileProvider struct {
+	Path string
+	key  []byte
+}
+
+// NewFileProvider returns a new FileProvider of the secrets file at path, encrypted with key.
+// The file is read once to check it can be decrypted.
+func NewFileProvider(path string, key []byte) (*FileProvider, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes long", KeySize)
+	}
+
+	p := &FileProvider{Path: path, key: key}
+	if _, err := p.readSecrets(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSecret returns the secret with the given name.
+func (p *FileProvider) GetSecret(name string) (string, error) {
+	secrets, err := p.readSecrets()
+	if err != nil {
+		return "", err
+	}
+
+	secret, ok := secrets[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+// readSecrets reads and decrypts the secrets file.
+func (p *FileProvider) readSecrets() (map[string]string, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := Decrypt(data, p.key)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting %s: %w", p.Path, err)
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", p.Path, err)
+	}
+	return secrets, nil
+}
+
+// ReadKeyFile reads a key from a file holding it base64 encoded (e.g. generated with 'openssl rand -base64 32').
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key in %s: %w", path, err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key in %s: must be %d bytes long", path, KeySize)
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext with AES-256-GCM, returning the nonce followed by the ciphertext.
+func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt decrypts data encrypted by Encrypt.
+func Decrypt(data []byte, key []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("data too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, nil)
+}
+
+// newAEAD returns the AES-GCM cipher of key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
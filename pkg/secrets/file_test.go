@@ -0,0 +1,59 @@
+package secrets_test
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	key := make([]byte, secrets.KeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600))
+	readKey, err := secrets.ReadKeyFile(keyPath)
+	require.NoError(t, err)
+	assert.Equal(t, key, readKey)
+
+	writeSecrets := func(path string, plaintext string) {
+		data, err := secrets.Encrypt([]byte(plaintext), key)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+	}
+
+	path := filepath.Join(dir, "secrets.enc")
+	writeSecrets(path, `{"db-password": "password 1"}`)
+
+	provider, err := secrets.NewFileProvider(path, key)
+	require.NoError(t, err)
+
+	secret, err := provider.GetSecret("db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "password 1", secret)
+
+	_, err = provider.GetSecret("unknown")
+	assert.ErrorIs(t, err, secrets.ErrSecretNotFound)
+
+	// Rotated secrets are picked up
+	writeSecrets(path, `{"db-password": "password 2"}`)
+	secret, err = provider.GetSecret("db-password")
+	require.NoError(t, err)
+	assert.Equal(t, "password 2", secret)
+
+	// The file can't be decrypted with another key
+	otherKey := make([]byte, secrets.KeySize)
+	_, err = secrets.NewFileProvider(path, otherKey)
+	assert.Error(t, err)
+
+	_, err = secrets.NewFileProvider(path, key[:16])
+	assert.Error(t, err)
+}
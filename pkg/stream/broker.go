@@ -0,0 +1,121 @@
+// Package stream broadcasts article events to in-process subscribers (e.g. server-sent events clients).
+package stream
+
+import (
+	"sync"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+)
+
+// subscriptionBuffer is the number of events a subscriber can lag behind before being dropped.
+const subscriptionBuffer = 100
+
+// Subscription receives the published events whose article matches its filters on C.
+// C is closed when the subscriber falls too far behind or the broker is closed.
+type Subscription struct {
+	C <-chan entities.Event
+	// After is the ID of the last event published when the subscription was made: it receives the events after it.
+	After uint64
+
+	ch       chan entities.Event
+	provider string
+	category string
+}
+
+// Broker broadcasts events to its subscriptions. It's a core.EventPublisher, meant to be published events in
+// the order of their IDs.
+type Broker struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+	closed        bool
+	// lastID is the ID of the last event published.
+	lastID uint64
+}
+
+// NewBroker returns a new Broker.
+func NewBroker() *Broker {
+	return &Broker{subscriptions: make(map[*Subscription]struct{})}
+}
+
+// Subscribe returns a subscription to the events of the articles of the given provider and category (empty
+// means all).
+// If the broker is closed, the subscription's channel is closed straight away.
+func (b *Broker) Subscribe(provider string, category string) *Subscription {
+	ch := make(chan entities.Event, subscriptionBuffer)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{C: ch, After: b.lastID, ch: ch, provider: provider, category: category}
+
+	if b.closed {
+		close(ch)
+		return sub
+	}
+
+	b.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes a subscription and closes its channel, if not closed already.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// Publish sends an event to the matching subscriptions without blocking. It never fails.
+// Subscriptions that can't keep up are dropped (their channel closed), so that they can resume from the
+// repository instead.
+func (b *Broker) Publish(event entities.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID = event.ID
+
+	for sub := range b.subscriptions {
+		if sub.provider != "" && sub.provider != event.Article.Provider {
+			continue
+		}
+		if sub.category != "" && sub.category != event.Article.Category {
+			continue
+		}
+
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+
+	return nil
+}
+
+// Seek sets the ID of the last event published, for the broker to start from the events after it (see
+// Subscription.After).
+func (b *Broker) Seek(lastID uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID = lastID
+}
+
+// Close closes all subscriptions and makes new ones closed from the start.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscriptions {
+		b.remove(sub)
+	}
+}
+
+// remove removes a subscription. The lock must be held.
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscriptions[sub]; ok {
+		delete(b.subscriptions, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,49 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/stream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBroker(t *testing.T) {
+	broker := stream.NewBroker()
+
+	all := broker.Subscribe("", "")
+	provider := broker.Subscribe("provider 1", "")
+	slow := broker.Subscribe("provider 2", "")
+
+	require.NoError(t, broker.Publish(entities.Event{ID: 1, Article: entities.Article{GUID: "guid 1", Provider: "provider 1"}}))
+
+	assert.Equal(t, uint64(1), (<-all.C).ID)
+	assert.Equal(t, "guid 1", (<-provider.C).Article.GUID)
+	assert.Len(t, slow.C, 0)
+
+	// Subscriptions receive the events after the last one published when they're made
+	assert.Equal(t, uint64(0), all.After)
+	assert.Equal(t, uint64(1), broker.Subscribe("", "").After)
+	broker.Seek(5)
+	assert.Equal(t, uint64(5), broker.Subscribe("", "").After)
+
+	// Subscribers lagging behind are dropped
+	for i := 0; i <= 100; i++ {
+		require.NoError(t, broker.Publish(entities.Event{Article: entities.Article{GUID: "guid", Provider: "provider 2"}}))
+	}
+	for range slow.C {
+	}
+	assert.Len(t, provider.C, 0)
+
+	broker.Unsubscribe(provider)
+	_, ok := <-provider.C
+	assert.False(t, ok)
+
+	broker.Close()
+	for range all.C {
+	}
+
+	_, ok = <-broker.Subscribe("", "").C
+	assert.False(t, ok)
+}
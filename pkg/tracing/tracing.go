@@ -0,0 +1,82 @@
+// Package tracing sets up OpenTelemetry tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName is the name of the service in traces.
+const ServiceName = "news-app-articles-mgmt-service"
+
+// TracerName is the name of the tracer creating the spans of the service.
+const TracerName = "github.com/gustavooferreira/news-app-articles-mgmt-service"
+
+// Exporter names.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Tracer returns the tracer creating the spans of the service, from the global tracer provider.
+// Spans are no-ops until a tracer provider is set with otel.SetTracerProvider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// NewExporter returns a span exporter: 'stdout' pretty prints spans to stderr (for local use, named after the
+// OpenTelemetry exporter, it keeps them apart from the logs written to stdout), 'otlp' sends them to an
+// OpenTelemetry collector over HTTP.
+// The OTLP endpoint (host:port) can be empty to use the default, or the standard OTEL_EXPORTER_OTLP_*
+// environment variables.
+func NewExporter(ctx context.Context, exporter string, otlpEndpoint string, otlpInsecure bool) (
+	sdktrace.SpanExporter, error) {
+
+	switch exporter {
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stderr), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if otlpEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(otlpEndpoint))
+		}
+		if otlpInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown exporter: %s", exporter)
+	}
+}
+
+// NewTracerProvider returns a tracer provider exporting spans in batches.
+// Traces started by the service are sampled at sampleRatio, traces continued from clients follow their decision.
+func NewTracerProvider(exporter sdktrace.SpanExporter, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	), nil
+}
+
+// Propagator returns the propagator of trace context between services: W3C trace context and baggage.
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for webhooks whose host is (or resolves to) an address that isn't public,
+// like loopback, link-local or private ones, so that webhooks can't be used to reach internal services.
+var ErrForbiddenAddress = errors.New("webhook host must be a public address")
+
+// forbiddenNetworks are the networks that aren't public besides the ones net.IP tells apart (loopback,
+// private, link-local, multicast and unspecified addresses).
+var forbiddenNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",     // "this" network
+		"100.64.0.0/10", // carrier-grade NAT
+		"192.0.0.0/24",  // IETF protocol assignments
+		"198.18.0.0/15", // benchmarking
+		"240.0.0.0/4",   // reserved, broadcast included
+		"64:ff9b::/96",  // NAT64, which can map to any IPv4 address
+	} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// IsPublicIP reports whether ip is a public address webhooks can be delivered to.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL checks that a webhook URL is an http(s) URL whose host resolves to public addresses only.
+// Deliveries are checked again when connecting, as the host may resolve to other addresses by then.
+func ValidateURL(ctx context.Context, rawURL string) error {
+	u, err := parseURL(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("webhook host can't be resolved: %s", u.Hostname())
+	}
+	for _, addr := range addrs {
+		if !IsPublicIP(addr.IP) {
+			return ErrForbiddenAddress
+		}
+	}
+	return nil
+}
+
+// parseURL parses a webhook URL, which must be an http(s) URL with a host.
+func parseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("invalid webhook URL")
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("webhook URL scheme must be http or https")
+	}
+	if u.Hostname() == "" {
+		return nil, errors.New("webhook URL must have a host")
+	}
+	return u, nil
+}
+
+// publicOnlyDialer returns a dialer refusing to connect to addresses that aren't public. The check happens
+// once the host is resolved, right before connecting, so that hosts can't be made to resolve to internal
+// addresses after they're validated.
+func publicOnlyDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !IsPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		},
+	}
+}
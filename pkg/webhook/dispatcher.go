@@ -0,0 +1,321 @@
+// Package webhook delivers newly added articles to the subscribed webhooks.
+//
+// Every delivery is recorded in the repository before being attempted, so that deliveries survive restarts,
+// failed deliveries can be retried (with exponential backoff) and the delivery log queried. Deliveries are only
+// made to public addresses.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+const (
+	// batchSize is the maximum number of due deliveries attempted at once.
+	batchSize = 100
+	// claimLease is how long the due deliveries claimed are reserved to the dispatcher attempting them, long
+	// enough for a whole batch to time out.
+	claimLease = 30 * time.Minute
+	// minBackoff is the delay before the first retry.
+	minBackoff = 30 * time.Second
+	// maxBackoff caps the delay between retries.
+	maxBackoff = time.Hour
+)
+
+// Dispatcher turns articles into deliveries for the matching webhooks and delivers them.
+type Dispatcher struct {
+	Logger log.Logger
+	Repo   core.Repository
+	Client *http.Client
+	// MaxAttempts is the number of attempts after which a delivery is marked as failed.
+	MaxAttempts int
+	// Tick is how often the repository is checked for deliveries due, besides when new ones are recorded.
+	Tick time.Duration
+
+	// wake is signalled when new deliveries are recorded, so that they're attempted right away.
+	wake chan struct{}
+	// random returns a number in [0.0,1.0) used to add jitter to the backoff.
+	random func() float64
+}
+
+// NewDispatcher returns a new Dispatcher.
+func NewDispatcher(logger log.Logger, repo core.Repository, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		Logger:      logger,
+		Repo:        repo,
+		Client:      newClient(),
+		MaxAttempts: maxAttempts,
+		Tick:        10 * time.Second,
+		wake:        make(chan struct{}, 1),
+		random:      rand.Float64,
+	}
+}
+
+// newClient returns the HTTP client deliveries are made with, which only connects to public addresses
+// (proxies included, so none is used).
+func newClient() *http.Client {
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         publicOnlyDialer().DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// Publish enqueues an 'article.created' event (see Enqueue). It's a core.EventPublisher, meant to be fed the
+// events of the outbox (see events.Relay), so that every article added is delivered.
+func (d *Dispatcher) Publish(event entities.Event) error {
+	if event.Type != entities.EventArticleCreated {
+		return nil
+	}
+	return d.Enqueue(event)
+}
+
+// Enqueue records a pending delivery of the event for every matching webhook and has them attempted right
+// away. Once it returns without error, the deliveries are attempted even if the service restarts.
+func (d *Dispatcher) Enqueue(event entities.Event) error {
+	if err := d.CreateDeliveries(event, time.Now()); err != nil {
+		return err
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+		// Already signalled
+	}
+	return nil
+}
+
+// Run attempts the deliveries that are due, as they're recorded and every tick, until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.Tick)
+	defer ticker.Stop()
+
+	for {
+		d.DeliverDue(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// CreateDeliveries records a pending delivery of the event for every webhook whose filters match its article.
+// The payload is the event's JSON, whose ID receivers can discard duplicates with.
+func (d *Dispatcher) CreateDeliveries(event entities.Event, now time.Time) error {
+	webhooks, err := d.Repo.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	article := event.Article
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var deliveries entities.WebhookDeliveries
+	for _, webhook := range webhooks {
+		if webhook.Provider != "" && webhook.Provider != article.Provider {
+			continue
+		}
+		if webhook.Category != "" && webhook.Category != article.Category {
+			continue
+		}
+
+		deliveries = append(deliveries, entities.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			ArticleGUID:   article.GUID,
+			Payload:       string(payload),
+			Status:        entities.DeliveryStatusPending,
+			CreatedAt:     now,
+			NextAttemptAt: &now,
+		})
+	}
+
+	return d.Repo.AddWebhookDeliveries(deliveries)
+}
+
+// DeliverDue claims the pending deliveries that are due and attempts them, recording the outcome of each one.
+// Every instance of the service delivers, and a delivery is only attempted by the one that claimed it: the others
+// don't attempt it again until the claim's lease expires.
+func (d *Dispatcher) DeliverDue(ctx context.Context, now time.Time) {
+	deliveries, err := d.Repo.ClaimDueWebhookDeliveries(now, claimLease, batchSize)
+	if err != nil {
+		d.Logger.Error(fmt.Sprintf("error claiming webhook deliveries: %s", err.Error()), log.Field("type", "webhook"))
+		return
+	}
+
+	if len(deliveries) == 0 {
+		return
+	}
+
+	webhooks, err := d.Repo.GetWebhooks()
+	if err != nil {
+		d.Logger.Error(fmt.Sprintf("error getting webhooks: %s", err.Error()), log.Field("type", "webhook"))
+		return
+	}
+
+	webhooksByID := make(map[uint64]entities.Webhook, len(webhooks))
+	for _, webhook := range webhooks {
+		webhooksByID[webhook.ID] = webhook
+	}
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+
+		webhook, ok := webhooksByID[delivery.WebhookID]
+		if !ok {
+			// Webhook deleted in the meantime
+			continue
+		}
+
+		delivery = d.attempt(ctx, webhook, delivery, now)
+
+		if err := d.Repo.UpdateWebhookDelivery(delivery); err != nil {
+			d.Logger.Error(fmt.Sprintf("error updating webhook delivery: %s", err.Error()),
+				log.Field("type", "webhook"), log.Field("delivery", delivery.ID))
+		}
+	}
+}
+
+// attempt delivers the payload to the webhook once and returns the delivery with its status updated.
+func (d *Dispatcher) attempt(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery,
+	now time.Time) entities.WebhookDelivery {
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.Error = ""
+
+	err := d.post(ctx, webhook, delivery, now)
+	if errT, ok := err.(*statusError); ok {
+		delivery.ResponseStatus = errT.StatusCode
+	}
+
+	if err == nil {
+		delivery.Status = entities.DeliveryStatusSucceeded
+		delivery.ResponseStatus = 200
+		delivery.NextAttemptAt = nil
+		return delivery
+	}
+
+	delivery.Error = err.Error()
+
+	if delivery.Attempts >= d.MaxAttempts {
+		delivery.Status = entities.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+	} else {
+		next := now.Add(d.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	d.Logger.Info(fmt.Sprintf("webhook delivery failed: %s", delivery.Error),
+		log.Field("type", "webhook"),
+		log.Fields(map[string]interface{}{
+			"webhook":  webhook.ID,
+			"delivery": delivery.ID,
+			"attempts": delivery.Attempts,
+			"status":   delivery.Status,
+		}))
+
+	return delivery
+}
+
+// statusError is returned when a webhook responds with a non 2xx status.
+type statusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %s", e.Status)
+}
+
+// post sends the signed payload to the webhook.
+func (d *Dispatcher) post(ctx context.Context, webhook entities.Webhook, delivery entities.WebhookDelivery,
+	now time.Time) error {
+
+	// The address is checked when connecting
+	if _, err := parseURL(webhook.URL); err != nil {
+		return err
+	}
+
+	body := []byte(delivery.Payload)
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "news-app-articles-mgmt-service")
+	req.Header.Set(HeaderDelivery, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, "sha256="+Sign(webhook.Secret, timestamp, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return nil
+}
+
+// backoff returns the delay before the next attempt of a delivery that failed a number of times.
+// The delay doubles with each attempt and is randomised within its upper half.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := minBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return delay/2 + time.Duration(d.random()*float64(delay/2))
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the timestamp and body, as sent in the signature header.
+// Receivers should compute it over "<timestamp>.<body>" with the shared secret and compare.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
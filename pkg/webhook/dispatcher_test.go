@@ -0,0 +1,220 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/mocks"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/entities"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/core/log"
+	"github.com/gustavooferreira/news-app-articles-mgmt-service/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDeliveries(t *testing.T) {
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+	article := entities.Article{GUID: "guid 1", Provider: "provider 1", Category: "category 1"}
+	event := entities.Event{ID: 7, Type: entities.EventArticleCreated, OccurredAt: now, Article: article}
+
+	webhooks := entities.Webhooks{
+		{ID: 1, URL: "http://all.example"},
+		{ID: 2, URL: "http://provider.example", Provider: "provider 1"},
+		{ID: 3, URL: "http://other-provider.example", Provider: "provider 2"},
+		{ID: 4, URL: "http://category.example", Provider: "provider 1", Category: "category 2"},
+	}
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetWebhooks").Return(webhooks, nil)
+	mockDB.On("AddWebhookDeliveries", mock.Anything).Return(nil)
+
+	dispatcher := webhook.NewDispatcher(log.NullLogger{}, mockDB, 3)
+	require.NoError(t, dispatcher.CreateDeliveries(event, now))
+
+	deliveries := mockDB.Calls[1].Arguments.Get(0).(entities.WebhookDeliveries)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, uint64(1), deliveries[0].WebhookID)
+	assert.Equal(t, uint64(2), deliveries[1].WebhookID)
+	assert.Equal(t, entities.DeliveryStatusPending, deliveries[0].Status)
+	assert.Equal(t, &now, deliveries[0].NextAttemptAt)
+
+	// The payload is the event, ID included
+	var payload entities.Event
+	require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, event, payload)
+}
+
+func TestDeliverDue(t *testing.T) {
+	const secret = "0123456789abcdef"
+	payload := `{"id":1,"type":"article.created","article":{"guid":"guid 1"}}`
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(500)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		if r.Header.Get(webhook.HeaderSignature) != "sha256="+webhook.Sign(secret, timestamp, body) {
+			w.WriteHeader(401)
+			return
+		}
+		w.WriteHeader(204)
+	}))
+	defer receiver.Close()
+
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	webhooks := entities.Webhooks{
+		{ID: 1, URL: receiver.URL + "/ok", Secret: secret},
+		{ID: 2, URL: receiver.URL + "/fail", Secret: secret},
+	}
+
+	deliveries := entities.WebhookDeliveries{
+		{ID: 10, WebhookID: 1, Payload: payload, Status: entities.DeliveryStatusPending, NextAttemptAt: &now},
+		{ID: 11, WebhookID: 2, Payload: payload, Status: entities.DeliveryStatusPending, NextAttemptAt: &now},
+		{ID: 12, WebhookID: 2, Payload: payload, Status: entities.DeliveryStatusPending, NextAttemptAt: &now,
+			Attempts: 2},
+	}
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("ClaimDueWebhookDeliveries", now, 30*time.Minute, mock.Anything).Return(deliveries, nil)
+	mockDB.On("GetWebhooks").Return(webhooks, nil)
+	mockDB.On("UpdateWebhookDelivery", mock.Anything).Return(nil)
+
+	// The receiver listens on a loopback address, which the dispatcher's own client refuses to connect to
+	dispatcher := webhook.NewDispatcher(log.NullLogger{}, mockDB, 3)
+	dispatcher.Client = receiver.Client()
+	dispatcher.DeliverDue(context.Background(), now)
+
+	mockDB.AssertNumberOfCalls(t, "UpdateWebhookDelivery", 3)
+	updated := make(map[uint64]entities.WebhookDelivery)
+	for _, call := range mockDB.Calls {
+		if call.Method == "UpdateWebhookDelivery" {
+			delivery := call.Arguments.Get(0).(entities.WebhookDelivery)
+			updated[delivery.ID] = delivery
+		}
+	}
+
+	// Signed and accepted
+	assert.Equal(t, entities.DeliveryStatusSucceeded, updated[10].Status)
+	assert.Equal(t, 1, updated[10].Attempts)
+	assert.Nil(t, updated[10].NextAttemptAt)
+
+	// Failed, retried later
+	assert.Equal(t, entities.DeliveryStatusPending, updated[11].Status)
+	assert.Equal(t, 500, updated[11].ResponseStatus)
+	require.NotNil(t, updated[11].NextAttemptAt)
+	assert.True(t, updated[11].NextAttemptAt.After(now))
+	assert.False(t, updated[11].NextAttemptAt.After(now.Add(30*time.Second)))
+
+	// Out of attempts
+	assert.Equal(t, entities.DeliveryStatusFailed, updated[12].Status)
+	assert.Equal(t, 3, updated[12].Attempts)
+	assert.Nil(t, updated[12].NextAttemptAt)
+}
+
+func TestPublish(t *testing.T) {
+	article := entities.Article{GUID: "guid 1", Provider: "provider 1", Category: "category 1"}
+	delivered := make(chan struct{}, 1)
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("GetWebhooks").Return(entities.Webhooks{{ID: 1, URL: "http://all.example"}}, nil)
+	mockDB.On("AddWebhookDeliveries", mock.Anything).Return(nil)
+	mockDB.On("ClaimDueWebhookDeliveries", mock.Anything, mock.Anything, mock.Anything).
+		Return(entities.WebhookDeliveries{}, nil).
+		Run(func(mock.Arguments) { delivered <- struct{}{} })
+
+	dispatcher := webhook.NewDispatcher(log.NullLogger{}, mockDB, 3)
+	dispatcher.Tick = time.Hour
+
+	// Deliveries of created articles are recorded before Publish returns
+	require.NoError(t, dispatcher.Publish(entities.Event{ID: 1, Type: entities.EventArticleCreated, Article: article}))
+	mockDB.AssertCalled(t, "AddWebhookDeliveries", mock.Anything)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	// Due deliveries are looked up on start, and again right away as deliveries were recorded
+	for i := 0; i < 2; i++ {
+		select {
+		case <-delivered:
+		case <-time.After(time.Second):
+			require.Fail(t, "due deliveries not looked up")
+		}
+	}
+}
+
+func TestDeliverRefusesInternalAddresses(t *testing.T) {
+	requests := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(204)
+	}))
+	defer receiver.Close()
+
+	now := time.Date(2020, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	mockDB := &mocks.Repository{}
+	mockDB.On("ClaimDueWebhookDeliveries", now, 30*time.Minute, mock.Anything).Return(entities.WebhookDeliveries{
+		{ID: 10, WebhookID: 1, Status: entities.DeliveryStatusPending, NextAttemptAt: &now},
+		{ID: 11, WebhookID: 2, Status: entities.DeliveryStatusPending, NextAttemptAt: &now},
+	}, nil)
+	mockDB.On("GetWebhooks").Return(entities.Webhooks{
+		{ID: 1, URL: receiver.URL},
+		{ID: 2, URL: "file:///etc/passwd"},
+	}, nil)
+	mockDB.On("UpdateWebhookDelivery", mock.Anything).Return(nil)
+
+	dispatcher := webhook.NewDispatcher(log.NullLogger{}, mockDB, 3)
+	dispatcher.DeliverDue(context.Background(), now)
+
+	assert.Equal(t, 0, requests)
+	for _, call := range mockDB.Calls {
+		if call.Method == "UpdateWebhookDelivery" {
+			delivery := call.Arguments.Get(0).(entities.WebhookDelivery)
+			assert.Equal(t, entities.DeliveryStatusPending, delivery.Status)
+			assert.NotEmpty(t, delivery.Error)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	tests := map[string]struct {
+		url         string
+		expectedErr bool
+	}{
+		"public address":   {url: "https://93.184.216.34/hook"},
+		"public ipv6":      {url: "https://[2606:2800:220:1:248:1893:25c8:1946]/hook"},
+		"unsupported":      {url: "ftp://93.184.216.34/hook", expectedErr: true},
+		"no host":          {url: "http:///hook", expectedErr: true},
+		"loopback":         {url: "http://127.0.0.1:8080/hook", expectedErr: true},
+		"localhost":        {url: "http://localhost:8080/hook", expectedErr: true},
+		"loopback ipv6":    {url: "http://[::1]/hook", expectedErr: true},
+		"private":          {url: "http://10.0.0.5/hook", expectedErr: true},
+		"link-local":       {url: "http://169.254.169.254/latest/meta-data", expectedErr: true},
+		"unspecified":      {url: "http://0.0.0.0/hook", expectedErr: true},
+		"carrier-grade":    {url: "http://100.64.0.1/hook", expectedErr: true},
+		"ipv4-mapped ipv6": {url: "http://[::ffff:10.0.0.5]/hook", expectedErr: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := webhook.ValidateURL(context.Background(), test.url)
+			if test.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}